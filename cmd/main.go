@@ -4,6 +4,9 @@ import (
 	"ffly-baisc/internal/config"
 	"ffly-baisc/internal/db"
 	"ffly-baisc/internal/router"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/auth"
+	"ffly-baisc/pkg/query"
 	"fmt"
 	"log"
 	"os"
@@ -25,9 +28,17 @@ func main() {
 		log.Fatalf("Failed to init config: %v\n", err)
 	}
 
+	// 初始化 JWT 签名密钥
+	if err := auth.InitKeys(); err != nil {
+		log.Fatalf("Failed to init jwt keys: %v\n", err)
+	}
+
 	// 初始化数据库
 	db.InitDB()
 
+	// 注册列表视图加载器（列表的 view 参数）
+	query.SetViewLoader(service.LoadSavedView)
+
 	// 初始化路由服务
 	router.Init()
 }
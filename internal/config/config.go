@@ -3,17 +3,39 @@ package config
 import "github.com/spf13/viper"
 
 type Config struct {
-	App   AppConfig
-	MySql MySqlConfig
-	Redis RedisConfig
+	App      AppConfig
+	MySql    MySqlConfig
+	Redis    RedisConfig
+	Mail     MailConfig
+	SMS      SMSConfig
+	Password PasswordConfig
+	Verify   VerifyConfig
+	OIDC     OIDCConfig
+	OAuth    OAuthConfig
+	LDAP     LDAPConfig
+	Captcha  CaptchaConfig
 }
 
 type AppConfig struct {
-	Name      string `mapstructure:"name"`
-	Mode      string `mapstructure:"mode"`
-	Port      int    `mapstructure:"port"`
-	JWTSecret string `mapstructure:"jwt_secret"`
-	JWTExpire int    `mapstructure:"jwt_expire"`
+	Name           string `mapstructure:"name"`
+	Mode           string `mapstructure:"mode"`
+	Port           int    `mapstructure:"port"`
+	JWTSecret      string `mapstructure:"jwt_secret"`
+	JWTExpire      int    `mapstructure:"jwt_expire"`
+	JWTAlgorithm   string `mapstructure:"jwt_algorithm"`    // 签名算法 HS256 / RS256 / EdDSA
+	JWTKeyDir      string `mapstructure:"jwt_key_dir"`      // 非对称签名密钥存放目录
+	JWTKeyRotation int    `mapstructure:"jwt_key_rotation"` // 非对称签名密钥轮换周期（秒）
+
+	LoginMaxFailures   int `mapstructure:"login_max_failures"`    // 同一用户名连续失败多少次后锁定
+	LoginIPMaxFailures int `mapstructure:"login_ip_max_failures"` // 同一 IP 失败多少次后锁定
+	LoginFailureWindow int `mapstructure:"login_failure_window"`  // 失败次数统计窗口（秒）
+	LoginLockDuration  int `mapstructure:"login_lock_duration"`   // 锁定时长（秒）
+	LoginBackoffBase   int `mapstructure:"login_backoff_base"`    // 失败后退避等待的基础时长（秒），每次失败翻倍
+	LoginBackoffMax    int `mapstructure:"login_backoff_max"`     // 退避等待的最大时长（秒）
+
+	AuthSource string `mapstructure:"auth_source"` // 默认认证方式 local / ldap，用户单独指定时以用户为准
+
+	ImpersonationExpire int `mapstructure:"impersonation_expire"` // 模拟登录最长有效期（秒）
 }
 
 type MySqlConfig struct {
@@ -36,6 +58,108 @@ type RedisConfig struct {
 	MinIdleConns int    `mapstructure:"min_idle_conns"`
 }
 
+type MailConfig struct {
+	Driver   string `mapstructure:"driver"` // log: 仅打印日志, smtp: 通过 SMTP 发送
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+type SMSConfig struct {
+	Driver string `mapstructure:"driver"` // log: 仅打印日志, fake: 保存在内存中（联调/测试），其他驱动通过 sms.RegisterDriver 注册
+	Login  bool   `mapstructure:"login"`  // 是否允许短信验证码登录
+}
+
+type PasswordConfig struct {
+	ResetURL        string   `mapstructure:"reset_url"`        // 重置密码页面地址，%s 会被替换为重置 Token
+	ResetExpire     int      `mapstructure:"reset_expire"`     // 重置 Token 有效期（秒）
+	MinLength       int      `mapstructure:"min_length"`       // 最小长度
+	RequireUpper    bool     `mapstructure:"require_upper"`    // 必须包含大写字母
+	RequireLower    bool     `mapstructure:"require_lower"`    // 必须包含小写字母
+	RequireDigit    bool     `mapstructure:"require_digit"`    // 必须包含数字
+	RequireSymbol   bool     `mapstructure:"require_symbol"`   // 必须包含特殊字符
+	BannedPasswords []string `mapstructure:"banned_passwords"` // 禁止使用的密码（不区分大小写）
+	HistorySize     int      `mapstructure:"history_size"`     // 不允许与最近 N 次密码重复，0 不限制
+	MaxAge          int      `mapstructure:"max_age"`          // 密码最长使用天数，超过后登录时提示修改，0 不限制
+}
+
+type VerifyConfig struct {
+	Required       bool `mapstructure:"required"`        // 注册后是否必须完成邮箱/手机号验证才能登录
+	CodeExpire     int  `mapstructure:"code_expire"`     // 验证码有效期（秒）
+	ResendInterval int  `mapstructure:"resend_interval"` // 重发间隔（秒）
+	MaxDailySends  int  `mapstructure:"max_daily_sends"` // 同一邮箱/手机号每天最多发送次数
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `mapstructure:"providers"` // 外部身份提供方
+}
+
+type OIDCProviderConfig struct {
+	Name          string            `mapstructure:"name"`           // 提供方标识，用于路由 /oidc/:provider
+	Title         string            `mapstructure:"title"`          // 登录页显示名称
+	Issuer        string            `mapstructure:"issuer"`         // 颁发者地址
+	ClientID      string            `mapstructure:"client_id"`      // 客户端ID
+	ClientSecret  string            `mapstructure:"client_secret"`  // 客户端密钥，公共客户端可为空
+	RedirectURL   string            `mapstructure:"redirect_url"`   // 回调地址，需在身份提供方登记
+	Scopes        []string          `mapstructure:"scopes"`         // 请求的 scope，默认 openid profile email
+	UsernameClaim string            `mapstructure:"username_claim"` // 用户名声明，默认 preferred_username
+	RoleClaim     string            `mapstructure:"role_claim"`     // 角色声明，支持 realm_access.roles 形式的嵌套路径
+	RoleMapping   []OIDCRoleMapping `mapstructure:"role_mapping"`   // 身份提供方角色与本系统角色的映射
+	DefaultRoles  []string          `mapstructure:"default_roles"`  // 新用户默认角色编码
+	AutoCreate    bool              `mapstructure:"auto_create"`    // 首次登录时自动创建用户
+	SyncRoles     bool              `mapstructure:"sync_roles"`     // 每次登录时按映射同步用户角色
+}
+
+type OIDCRoleMapping struct {
+	Claim string `mapstructure:"claim"` // 身份提供方角色
+	Role  string `mapstructure:"role"`  // 本系统角色编码
+}
+
+type OAuthConfig struct {
+	Issuer     string `mapstructure:"issuer"`      // 授权服务器对外地址，同时作为 Token 的 iss
+	ConsentURL string `mapstructure:"consent_url"` // 前端授权确认页面地址，授权请求参数会原样附加
+	CodeExpire int    `mapstructure:"code_expire"` // 授权码有效期（秒）
+}
+
+type LDAPConfig struct {
+	URL                string             `mapstructure:"url"`                  // 服务地址，如 ldap://127.0.0.1:389、ldaps://ad.example.com:636
+	StartTLS           bool               `mapstructure:"start_tls"`            // ldap:// 连接后升级为 TLS
+	InsecureSkipVerify bool               `mapstructure:"insecure_skip_verify"` // 跳过证书校验，仅用于测试环境
+	BindDN             string             `mapstructure:"bind_dn"`              // 服务账号 DN，用于搜索用户
+	BindPassword       string             `mapstructure:"bind_password"`        // 服务账号密码
+	BaseDN             string             `mapstructure:"base_dn"`              // 用户搜索根
+	UserFilter         string             `mapstructure:"user_filter"`          // 用户搜索过滤器，默认 (uid=%s)，AD 可使用 (sAMAccountName=%s)
+	UsernameAttribute  string             `mapstructure:"username_attribute"`   // 用户名属性，默认 uid
+	EmailAttribute     string             `mapstructure:"email_attribute"`      // 邮箱属性，默认 mail
+	NicknameAttribute  string             `mapstructure:"nickname_attribute"`   // 昵称属性，默认 cn
+	GroupAttribute     string             `mapstructure:"group_attribute"`      // 用户条目上的组属性，默认 memberOf
+	GroupBaseDN        string             `mapstructure:"group_base_dn"`        // 组搜索根，目录不支持 memberOf 时配置
+	GroupFilter        string             `mapstructure:"group_filter"`         // 组搜索过滤器，默认 (member=%s)
+	GroupMapping       []LDAPGroupMapping `mapstructure:"group_mapping"`        // 目录组与本系统角色的映射
+	DefaultRoles       []string           `mapstructure:"default_roles"`        // 新用户默认角色编码
+	AutoCreate         bool               `mapstructure:"auto_create"`          // 首次登录时自动创建用户
+	SyncRoles          bool               `mapstructure:"sync_roles"`           // 每次登录时按映射同步用户角色
+	Timeout            int                `mapstructure:"timeout"`              // 连接和请求超时（秒）
+}
+
+type LDAPGroupMapping struct {
+	Group string `mapstructure:"group"` // 目录组，完整 DN 或组名（CN）
+	Role  string `mapstructure:"role"`  // 本系统角色编码
+}
+
+type CaptchaConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`         // 是否启用验证码
+	Type           string `mapstructure:"type"`            // digit: 数字验证码, arithmetic: 算术验证码
+	Length         int    `mapstructure:"length"`          // 数字验证码位数
+	Width          int    `mapstructure:"width"`           // 图片宽度
+	Height         int    `mapstructure:"height"`          // 图片高度
+	Expire         int    `mapstructure:"expire"`          // 有效期（秒）
+	LoginThreshold int    `mapstructure:"login_threshold"` // 用户名或 IP 登录失败多少次后需要验证码，0 表示始终需要
+	Register       bool   `mapstructure:"register"`        // 注册是否需要验证码
+}
+
 var (
 	GlobalConfig Config
 )
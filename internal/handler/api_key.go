@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCurrentUserApiKeys 获取当前用户的 API Key 列表
+func GetCurrentUserApiKeys(c *gin.Context) {
+	var apiKeyService service.ApiKeyService
+
+	apiKeys, err := apiKeyService.GetUserApiKeys(c.GetUint("userID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取 API Key 失败", err)
+		return
+	}
+
+	response.Success(c, apiKeys, nil, "获取成功")
+}
+
+// CreateCurrentUserApiKey 当前用户创建 API Key
+func CreateCurrentUserApiKey(c *gin.Context) {
+	var apiKeyService service.ApiKeyService
+
+	var apiKeyCreateRequest model.ApiKeyCreateRequest
+	if err := c.ShouldBindJSON(&apiKeyCreateRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	result, err := apiKeyService.CreateApiKey(c.GetUint("userID"), &apiKeyCreateRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建 API Key 失败", err)
+		return
+	}
+
+	response.Success(c, result, nil, "创建成功，请妥善保存 API Key，关闭后将无法再次查看")
+}
+
+// DeleteCurrentUserApiKey 当前用户吊销 API Key
+func DeleteCurrentUserApiKey(c *gin.Context) {
+	var apiKeyService service.ApiKeyService
+
+	id, err := strconv.ParseUint(c.Param("keyId"), 10, 64) // 解析 API Key ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := apiKeyService.DeleteApiKey(c.GetUint("userID"), uint(id)); err != nil {
+		response.Error(c, http.StatusInternalServerError, "吊销 API Key 失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "吊销成功")
+}
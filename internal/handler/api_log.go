@@ -3,7 +3,6 @@ package handler
 import (
 	"ffly-baisc/internal/service"
 	"ffly-baisc/pkg/response"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +13,7 @@ func GetApiLogList(c *gin.Context) {
 
 	apiLogs, pagination, err := apiLogService.GetApiLogList(c)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "获取日志列表失败", err)
+		listError(c, "获取日志列表失败", err)
 		return
 	}
 
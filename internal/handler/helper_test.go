@@ -0,0 +1,50 @@
+package handler_test
+
+import (
+	"ffly-baisc/internal/db"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+)
+
+// newMockRedis 使用 miniredis 替换 db.DB.Redis，测试结束后恢复
+func newMockRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	old := db.DB
+	schema := &db.DbSchema{}
+	if old != nil {
+		*schema = *old
+	}
+	schema.Redis = client
+	db.DB = schema
+	t.Cleanup(func() {
+		db.DB = old
+		client.Close()
+	})
+
+	return server
+}
+
+// newEngine 创建测试路由，请求以 userID 用户的身份访问
+func newEngine(userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Set("userID", userID) })
+
+	return engine
+}
+
+// serve 发送请求并返回响应
+func serve(engine *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+
+	return recorder
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"ffly-baisc/pkg/auth"
+	"ffly-baisc/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS 获取 JWT 验签公钥集合，供下游服务验证 Token
+func GetJWKS(c *gin.Context) {
+	jwks, err := auth.GetJWKS()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取公钥失败", err)
+		return
+	}
+
+	// JWKS 为标准格式，直接返回，不使用统一响应结构
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
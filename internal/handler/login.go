@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"ffly-baisc/internal/service"
-	"ffly-baisc/pkg/auth"
 	"ffly-baisc/pkg/response"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,15 +19,150 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	login.ClientIP = c.ClientIP()
+	login.UserAgent = c.Request.UserAgent()
+
+	token, err := login.Login()
+	if err != nil {
+		if loginLimitError(c, err) {
+			return
+		}
+		status := http.StatusUnauthorized
+		var captchaErr *service.CaptchaError
+		if errors.As(err, &captchaErr) {
+			status = http.StatusBadRequest
+		}
+		// 需要验证码时告知前端，下次登录前先获取验证码
+		if login.CaptchaRequired() {
+			response.ErrorWithData(c, status, "用户名或密码错误", err, gin.H{"captchaRequired": true})
+			return
+		}
+		response.Error(c, status, "用户名或密码错误", err)
+		return
+	}
+
+	response.Success(c, token, nil, "登录成功")
+}
+
+// SendLoginSMSCode 发送短信登录验证码
+func SendLoginSMSCode(c *gin.Context) {
+	var smsLoginCode service.SMSLoginCodeService
+
+	if err := c.ShouldBindJSON(&smsLoginCode); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	smsLoginCode.ClientIP = c.ClientIP()
+	smsLoginCode.UserAgent = c.Request.UserAgent()
+
+	if err := smsLoginCode.Send(); err != nil {
+		if loginLimitError(c, err) {
+			return
+		}
+		var captchaErr *service.CaptchaError
+		if errors.As(err, &captchaErr) {
+			response.ErrorWithData(c, http.StatusBadRequest, "验证码错误", err, gin.H{"captchaRequired": true})
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "发送验证码失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "验证码已发送")
+}
+
+// LoginSMS 短信验证码登录
+func LoginSMS(c *gin.Context) {
+	var login service.SMSLoginService
+
+	if err := c.ShouldBindJSON(&login); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	login.ClientIP = c.ClientIP()
+	login.UserAgent = c.Request.UserAgent()
+
 	token, err := login.Login()
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, "用户名或密码错误", err)
+		if loginLimitError(c, err) {
+			return
+		}
+		response.Error(c, http.StatusUnauthorized, "验证码错误", err)
 		return
 	}
 
 	response.Success(c, token, nil, "登录成功")
 }
 
+// GetCaptcha 获取图片验证码
+func GetCaptcha(c *gin.Context) {
+	var captchaService service.CaptchaService
+
+	captcha, err := captchaService.Generate()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取验证码失败", err)
+		return
+	}
+
+	response.Success(c, captcha, nil, "获取成功")
+}
+
+// LoginTwoFactor 两步验证登录，使用挑战 Token 和验证码换取 Token 对
+func LoginTwoFactor(c *gin.Context) {
+	var login service.TwoFactorLoginService
+
+	if err := c.ShouldBindJSON(&login); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	login.ClientIP = c.ClientIP()
+	login.UserAgent = c.Request.UserAgent()
+
+	token, err := login.Login()
+	if err != nil {
+		if loginLimitError(c, err) {
+			return
+		}
+		response.Error(c, http.StatusUnauthorized, "两步验证失败", err)
+		return
+	}
+
+	response.Success(c, token, nil, "登录成功")
+}
+
+// LoginTwoFactorSetup 角色要求两步验证的用户首次登录时获取绑定信息
+func LoginTwoFactorSetup(c *gin.Context) {
+	var login service.TwoFactorLoginService
+
+	if err := c.ShouldBindJSON(&login); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	setup, err := login.Setup()
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "获取两步验证绑定信息失败", err)
+		return
+	}
+
+	response.Success(c, setup, nil, "获取成功")
+}
+
+// loginLimitError 登录被限制时返回 429 并设置 Retry-After
+func loginLimitError(c *gin.Context, err error) bool {
+	var limitErr *service.LoginLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	response.Error(c, http.StatusTooManyRequests, "登录失败次数过多", err)
+	return true
+}
+
 func Register(c *gin.Context) {
 	var register service.RegisterService
 
@@ -36,6 +173,11 @@ func Register(c *gin.Context) {
 
 	err := register.Register()
 	if err != nil {
+		var captchaErr *service.CaptchaError
+		if errors.As(err, &captchaErr) {
+			response.ErrorWithData(c, http.StatusBadRequest, "验证码错误", err, gin.H{"captchaRequired": true})
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "注册失败", err)
 		return
 	}
@@ -60,8 +202,9 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 刷新 Access Token
-	tokenPair, err := auth.RefreshAccessToken(tokenString)
+	// 刷新 Access Token（同时轮换 Refresh Token）
+	var tokenService service.TokenService
+	tokenPair, err := tokenService.RefreshTokenPair(tokenString)
 	if err != nil {
 		c.Header("refresh_token_expired", "true")
 		response.Error(c, http.StatusUnauthorized, "登录超时，请重新登录", err)
@@ -70,3 +213,15 @@ func RefreshToken(c *gin.Context) {
 
 	response.Success(c, tokenPair, nil, "Token 刷新成功")
 }
+
+// Logout 退出登录，注销当前登录会话的所有 Token
+func Logout(c *gin.Context) {
+	var tokenService service.TokenService
+
+	if err := tokenService.RevokeFamily(c.GetUint("userID"), c.GetString("tokenFamilyID")); err != nil {
+		response.Error(c, http.StatusInternalServerError, "退出登录失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "退出登录成功")
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOpenIDConfiguration OpenID Provider 元数据（/.well-known/openid-configuration）
+func GetOpenIDConfiguration(c *gin.Context) {
+	var oauthService service.OAuthService
+
+	// 标准格式，直接返回，不使用统一响应结构
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, oauthService.Discovery())
+}
+
+// OAuthAuthorize 授权端点，校验客户端和回调地址后跳转到前端授权确认页面
+func OAuthAuthorize(c *gin.Context) {
+	var oauthService service.OAuthService
+
+	var authorizeRequest model.OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&authorizeRequest); err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	if _, _, err := oauthService.ValidateAuthorizeRequest(&authorizeRequest); err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	consentURL := config.GlobalConfig.OAuth.ConsentURL
+	separator := "?"
+	if strings.Contains(consentURL, "?") {
+		separator = "&"
+	}
+	c.Redirect(http.StatusFound, consentURL+separator+c.Request.URL.RawQuery)
+}
+
+// GetOAuthConsent 获取授权确认页信息（需要登录）
+func GetOAuthConsent(c *gin.Context) {
+	var oauthService service.OAuthService
+
+	var authorizeRequest model.OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&authorizeRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	consentInfo, err := oauthService.GetConsentInfo(c.GetUint("userID"), &authorizeRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "授权请求无效", err)
+		return
+	}
+
+	response.Success(c, consentInfo, nil, "获取成功")
+}
+
+// OAuthConsent 用户确认或拒绝授权（需要登录），返回前端需要跳转的回调地址
+func OAuthConsent(c *gin.Context) {
+	var oauthService service.OAuthService
+
+	var consentRequest model.OAuthConsentRequest
+	if err := c.ShouldBindJSON(&consentRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	result, err := oauthService.Consent(c.GetUint("userID"), &consentRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "授权失败", err)
+		return
+	}
+
+	response.Success(c, result, nil, "授权成功")
+}
+
+// OAuthToken 令牌端点，支持 client_secret_basic 和 client_secret_post
+func OAuthToken(c *gin.Context) {
+	var tokenService service.OAuthTokenService
+
+	if err := c.ShouldBind(&tokenService); err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	// client_secret_basic，客户端ID和密钥按 application/x-www-form-urlencoded 编码
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		tokenService.ClientID, _ = url.QueryUnescape(clientID)
+		tokenService.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	tokenResponse, err := tokenService.Token()
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, tokenResponse)
+}
+
+// OAuthUserinfo 用户信息端点
+func OAuthUserinfo(c *gin.Context) {
+	var oauthService service.OAuthService
+
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		oauthError(c, &service.OAuthError{Status: http.StatusUnauthorized, Code: "invalid_token", Description: "未提供 Access Token"})
+		return
+	}
+
+	claims, err := oauthService.Userinfo(strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
+
+// oauthError 按 OAuth2 标准格式返回错误
+func oauthError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = &service.OAuthError{Status: http.StatusBadRequest, Code: "invalid_request", Description: err.Error()}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(oauthErr.Status, oauthErr)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOAuthClientList 获取客户端列表
+func GetOAuthClientList(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	clients, pagination, err := clientService.GetOAuthClientList(c)
+	if err != nil {
+		listError(c, "获取客户端列表失败", err)
+		return
+	}
+
+	response.SuccessWithPagination(c, clients, pagination, "获取成功")
+}
+
+// GetOAuthClient 获取客户端信息
+func GetOAuthClient(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析客户端ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	client, err := clientService.GetOAuthClientByID(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取客户端信息失败", err)
+		return
+	}
+
+	response.Success(c, client, nil, "获取成功")
+}
+
+// CreateOAuthClient 创建客户端
+func CreateOAuthClient(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	var clientCreateRequest model.OAuthClientCreateRequest
+	if err := c.ShouldBindJSON(&clientCreateRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	result, err := clientService.CreateOAuthClient(&clientCreateRequest)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "创建客户端失败", err)
+		return
+	}
+
+	response.Success(c, result, nil, "创建成功，请妥善保存客户端密钥，关闭后将无法再次查看")
+}
+
+// PatchOAuthClient 更新部分客户端信息
+func PatchOAuthClient(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析客户端ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	var clientPatchRequest model.OAuthClientPatchRequest
+	if err := c.ShouldBindJSON(&clientPatchRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := clientService.PatchOAuthClient(uint(id), &clientPatchRequest); err != nil {
+		response.Error(c, http.StatusInternalServerError, "更新客户端失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "更新成功")
+}
+
+// ResetOAuthClientSecret 重置客户端密钥
+func ResetOAuthClientSecret(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析客户端ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	result, err := clientService.ResetOAuthClientSecret(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "重置客户端密钥失败", err)
+		return
+	}
+
+	response.Success(c, result, nil, "重置成功，请妥善保存客户端密钥，关闭后将无法再次查看")
+}
+
+// DeleteOAuthClient 删除客户端
+func DeleteOAuthClient(c *gin.Context) {
+	var clientService service.OAuthClientService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析客户端ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := clientService.DeleteOAuthClient(uint(id)); err != nil {
+		response.Error(c, http.StatusInternalServerError, "删除客户端失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "删除成功")
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcStateCookie = "oidc_state"    // 发起登录的浏览器保存的 state
+	oidcCookiePath  = "/api/v1/oidc/" // 只在登录和回调时发送
+)
+
+// GetOIDCProviders 获取外部身份提供方列表（用于登录页展示）
+func GetOIDCProviders(c *gin.Context) {
+	var oidcService service.OIDCService
+
+	response.Success(c, oidcService.GetProviders(), nil, "获取成功")
+}
+
+// OIDCLogin 跳转到身份提供方登录
+func OIDCLogin(c *gin.Context) {
+	var oidcService service.OIDCService
+
+	authURL, state, err := oidcService.AuthURL(c.Param("provider"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "获取登录地址失败", err)
+		return
+	}
+
+	// 将授权请求绑定到当前浏览器，身份提供方跳转回来时是顶级导航，SameSite=Lax 的 Cookie 会被发送
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(service.OIDCStateExpire.Seconds()), oidcCookiePath, "", c.Request.TLS != nil, true)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 身份提供方登录回调，校验通过后签发 Token 对（需要两步验证时返回挑战 Token）
+func OIDCCallback(c *gin.Context) {
+	var callback service.OIDCCallbackService
+
+	if err := c.ShouldBindQuery(&callback); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	callback.BrowserState, _ = c.Cookie(oidcStateCookie)
+	callback.ClientIP = c.ClientIP()
+	callback.UserAgent = c.Request.UserAgent()
+
+	// state 只能使用一次，无论登录是否成功都清除 Cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", c.Request.TLS != nil, true)
+
+	token, err := callback.Callback(c.Param("provider"))
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "登录失败", err)
+		return
+	}
+
+	response.Success(c, token, nil, "登录成功")
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword 忘记密码，发送重置邮件
+func ForgotPassword(c *gin.Context) {
+	var forgot service.ForgotPasswordService
+
+	if err := c.ShouldBindJSON(&forgot); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := forgot.Forgot(); err != nil {
+		response.Error(c, http.StatusTooManyRequests, "发送重置邮件失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "如果该邮箱已注册，您将收到一封重置密码邮件")
+}
+
+// ResetPassword 使用重置 Token 设置新密码
+func ResetPassword(c *gin.Context) {
+	var reset service.ResetPasswordService
+
+	if err := c.ShouldBindJSON(&reset); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := reset.Reset(); err != nil {
+		response.Error(c, http.StatusBadRequest, "重置密码失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "重置密码成功")
+}
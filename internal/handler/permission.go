@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"ffly-baisc/internal/model"
 	"ffly-baisc/internal/service"
 	"ffly-baisc/pkg/response"
@@ -59,6 +60,27 @@ func GetCurrentUserPermission(c *gin.Context) {
 	response.Success(c, list, nil, "菜单列表获取成功")
 }
 
+// GetCurrentUserPermissionCodes 获取当前用户的权限码列表（用于前端按钮级权限控制）
+func GetCurrentUserPermissionCodes(c *gin.Context) {
+	var authPermissionService service.AuthPermissionService
+
+	userID := c.GetUint("userID")
+
+	codes, err := authPermissionService.GetUserPermissionCodes(userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取权限码失败", err)
+		return
+	}
+
+	// 使用 API Key 访问时只返回 Key 权限范围内的权限码
+	if scopes, exists := c.Get("apiKeyScopes"); exists {
+		var apiKeyService service.ApiKeyService
+		codes = apiKeyService.FilterScopes(scopes.([]string), codes)
+	}
+
+	response.Success(c, codes, nil, "权限码获取成功")
+}
+
 // CreatePermission 创建菜单
 func CreatePermission(c *gin.Context) {
 	var permissionService service.PermissionService
@@ -72,6 +94,10 @@ func CreatePermission(c *gin.Context) {
 
 	// 创建菜单
 	if err := permissionService.CreatePermission(&permissionCreatedRequest); err != nil {
+		if errors.Is(err, service.ErrPermissionCodeRequired) {
+			response.Error(c, http.StatusBadRequest, "参数错误", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "创建菜单失败", err)
 		return
 	}
@@ -94,6 +120,10 @@ func PutPermission(c *gin.Context) {
 
 	// 创建菜单
 	if err := permissionService.PutPermission(id, &permissionCreatedRequest); err != nil {
+		if errors.Is(err, service.ErrPermissionCodeRequired) {
+			response.Error(c, http.StatusBadRequest, "参数错误", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "创建菜单失败", err)
 		return
 	}
@@ -117,6 +147,10 @@ func PatchPermission(c *gin.Context) {
 	}
 
 	if err := permissionService.PatchPermission(uint(id), &permissionPatchRequest); err != nil {
+		if errors.Is(err, service.ErrPermissionCodeRequired) {
+			response.Error(c, http.StatusBadRequest, "参数错误", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "更新菜单信息失败", err)
 		return
 	}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"errors"
+	"ffly-baisc/pkg/query"
+	"ffly-baisc/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listError 获取列表失败，查询参数错误（未知字段、非法的值等）时返回 400
+func listError(c *gin.Context, message string, err error) {
+	var paramErr *query.ParamError
+	if errors.As(err, &paramErr) {
+		response.Error(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	response.Error(c, http.StatusInternalServerError, message, err)
+}
+
+// GetSearchParamsSchema 获取列表查询 params 参数的 JSON Schema，前端据此校验查询条件
+func GetSearchParamsSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", query.SearchParamsSchema)
+}
@@ -16,7 +16,7 @@ func GetRoleList(c *gin.Context) {
 
 	roles, pagination, err := roleService.GetRoleList(c)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "获取角色列表失败", err)
+		listError(c, "获取角色列表失败", err)
 		return
 	}
 
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSavedViews 获取当前用户可以使用的列表视图，可按 resource 过滤
+func GetSavedViews(c *gin.Context) {
+	var savedViewService service.SavedViewService
+
+	views, err := savedViewService.GetSavedViews(c.GetUint("userID"), c.Query("resource"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取视图列表失败", err)
+		return
+	}
+
+	response.Success(c, views, nil, "获取成功")
+}
+
+// CreateSavedView 创建列表视图
+func CreateSavedView(c *gin.Context) {
+	var savedViewService service.SavedViewService
+
+	var savedViewCreateRequest model.SavedViewCreateRequest
+	if err := c.ShouldBindJSON(&savedViewCreateRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	view, err := savedViewService.CreateSavedView(c.GetUint("userID"), &savedViewCreateRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "创建视图失败", err)
+		return
+	}
+
+	response.Success(c, view, nil, "创建成功")
+}
+
+// PatchSavedView 更新列表视图
+func PatchSavedView(c *gin.Context) {
+	var savedViewService service.SavedViewService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析视图ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	var savedViewPatchRequest model.SavedViewPatchRequest
+	if err := c.ShouldBindJSON(&savedViewPatchRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	view, err := savedViewService.PatchSavedView(c.GetUint("userID"), uint(id), &savedViewPatchRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "更新视图失败", err)
+		return
+	}
+
+	response.Success(c, view, nil, "更新成功")
+}
+
+// DeleteSavedView 删除列表视图
+func DeleteSavedView(c *gin.Context) {
+	var savedViewService service.SavedViewService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析视图ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := savedViewService.DeleteSavedView(c.GetUint("userID"), uint(id)); err != nil {
+		response.Error(c, http.StatusBadRequest, "删除视图失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "删除成功")
+}
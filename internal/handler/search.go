@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	searchDefaultLimit = 20 // 默认返回的结果数量
+	searchMaxLimit     = 50 // 最多返回的结果数量
+)
+
+// Search 全局搜索用户、角色和权限，只搜索当前用户有列表权限的数据
+func Search(c *gin.Context) {
+	var searchService service.SearchService
+	var authPermissionService service.AuthPermissionService
+
+	terms, err := searchService.ParseSearchTerms(c.Query("q"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	limit := searchDefaultLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > searchMaxLimit {
+			response.Error(c, http.StatusBadRequest, "参数错误", nil)
+			return
+		}
+	}
+
+	codes, err := authPermissionService.GetUserPermissionCodes(c.GetUint("userID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "权限检查失败", err)
+		return
+	}
+
+	// 使用 API Key 访问时只搜索 Key 权限范围内的数据
+	if scopes, exists := c.Get("apiKeyScopes"); exists {
+		var apiKeyService service.ApiKeyService
+		codes = apiKeyService.FilterScopes(scopes.([]string), codes)
+	}
+
+	types := searchService.AllowedTypes(codes)
+	if len(types) == 0 {
+		response.Error(c, http.StatusForbidden, "权限不足", nil)
+		return
+	}
+
+	results, err := searchService.Search(types, terms, limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "搜索失败", err)
+		return
+	}
+
+	response.Success(c, results, nil, "搜索成功")
+}
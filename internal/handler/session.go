@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCurrentUserSessions 获取当前用户的登录设备列表
+func GetCurrentUserSessions(c *gin.Context) {
+	var sessionService service.SessionService
+
+	sessions, err := sessionService.GetUserSessions(c.GetUint("userID"), c.GetString("tokenFamilyID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取登录设备失败", err)
+		return
+	}
+
+	response.Success(c, sessions, nil, "获取成功")
+}
+
+// DeleteCurrentUserSession 注销当前用户的某个登录设备
+func DeleteCurrentUserSession(c *gin.Context) {
+	var sessionService service.SessionService
+
+	if err := sessionService.DeleteUserSession(c.GetUint("userID"), c.Param("sessionId")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.Error(c, http.StatusNotFound, "会话不存在", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "注销登录设备失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "注销成功")
+}
+
+// DeleteCurrentUserOtherSessions 注销当前用户除当前设备外的所有登录设备
+func DeleteCurrentUserOtherSessions(c *gin.Context) {
+	var sessionService service.SessionService
+
+	if err := sessionService.DeleteUserSessions(c.GetUint("userID"), c.GetString("tokenFamilyID")); err != nil {
+		response.Error(c, http.StatusInternalServerError, "注销登录设备失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "注销成功")
+}
+
+// GetUserSessions 获取指定用户的登录设备列表
+func GetUserSessions(c *gin.Context) {
+	var sessionService service.SessionService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	sessions, err := sessionService.GetUserSessions(uint(id), c.GetString("tokenFamilyID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取登录设备失败", err)
+		return
+	}
+
+	response.Success(c, sessions, nil, "获取成功")
+}
+
+// DeleteUserSession 注销指定用户的某个登录设备
+func DeleteUserSession(c *gin.Context) {
+	var sessionService service.SessionService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := sessionService.DeleteUserSession(uint(id), c.Param("sessionId")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.Error(c, http.StatusNotFound, "会话不存在", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "注销登录设备失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "注销成功")
+}
+
+// DeleteUserSessions 注销指定用户的所有登录设备
+func DeleteUserSessions(c *gin.Context) {
+	var sessionService service.SessionService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := sessionService.DeleteUserSessions(uint(id), ""); err != nil {
+		response.Error(c, http.StatusInternalServerError, "注销登录设备失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "注销成功")
+}
@@ -0,0 +1,47 @@
+package handler_test
+
+import (
+	"ffly-baisc/internal/handler"
+	"net/http"
+	"testing"
+)
+
+// TestDeleteSession 会话不存在或属于其他用户时返回 404，且不影响其他用户的会话
+func TestDeleteSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{"注销自己的会话", "/info/sessions/own", http.StatusOK},
+		{"会话不存在", "/info/sessions/unknown", http.StatusNotFound},
+		{"其他用户的会话", "/info/sessions/foreign", http.StatusNotFound},
+		{"管理员注销指定用户的会话", "/1/sessions/own", http.StatusOK},
+		{"会话不属于指定用户", "/1/sessions/foreign", http.StatusNotFound},
+		{"用户ID错误", "/abc/sessions/own", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockRedis(t)
+			server.SAdd("token:user:1", "own")
+			server.HSet("token:family:own", "user_id", "1")
+			server.SAdd("token:user:2", "foreign")
+			server.HSet("token:family:foreign", "user_id", "2")
+
+			engine := newEngine(1)
+			engine.DELETE("/info/sessions/:sessionId", handler.DeleteCurrentUserSession)
+			engine.DELETE("/:id/sessions/:sessionId", handler.DeleteUserSession)
+
+			recorder := serve(engine, http.MethodDelete, tt.target)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d，期望 %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if !server.Exists("token:family:foreign") {
+				t.Error("其他用户的会话不应被注销")
+			}
+			if tt.wantStatus == http.StatusOK && server.Exists("token:family:own") {
+				t.Error("会话应被注销")
+			}
+		})
+	}
+}
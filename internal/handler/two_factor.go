@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCurrentUserTwoFactor 获取当前用户的两步验证绑定信息
+func SetupCurrentUserTwoFactor(c *gin.Context) {
+	var twoFactorService service.TwoFactorService
+
+	setup, err := twoFactorService.Setup(c.GetUint("userID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取两步验证绑定信息失败", err)
+		return
+	}
+
+	response.Success(c, setup, nil, "获取成功")
+}
+
+// EnableCurrentUserTwoFactor 当前用户开启两步验证
+func EnableCurrentUserTwoFactor(c *gin.Context) {
+	var twoFactorService service.TwoFactorService
+
+	var codeRequest model.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	recoveryCodes, err := twoFactorService.Enable(c.GetUint("userID"), codeRequest.Code)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "开启两步验证失败", err)
+		return
+	}
+
+	response.Success(c, recoveryCodes, nil, "开启成功，请妥善保存恢复码")
+}
+
+// DisableCurrentUserTwoFactor 当前用户关闭两步验证
+func DisableCurrentUserTwoFactor(c *gin.Context) {
+	var twoFactorService service.TwoFactorService
+
+	var codeRequest model.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := twoFactorService.Disable(c.GetUint("userID"), codeRequest.Code); err != nil {
+		response.Error(c, http.StatusBadRequest, "关闭两步验证失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "关闭成功")
+}
+
+// RegenerateCurrentUserRecoveryCodes 当前用户重新生成恢复码
+func RegenerateCurrentUserRecoveryCodes(c *gin.Context) {
+	var twoFactorService service.TwoFactorService
+
+	var codeRequest model.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	recoveryCodes, err := twoFactorService.RegenerateRecoveryCodes(c.GetUint("userID"), codeRequest.Code)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "生成恢复码失败", err)
+		return
+	}
+
+	response.Success(c, recoveryCodes, nil, "生成成功，请妥善保存恢复码")
+}
+
+// ResetUserTwoFactor 重置指定用户的两步验证
+func ResetUserTwoFactor(c *gin.Context) {
+	var twoFactorService service.TwoFactorService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	if err := twoFactorService.Reset(uint(id)); err != nil {
+		response.Error(c, http.StatusInternalServerError, "重置两步验证失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "重置成功")
+}
@@ -16,7 +16,7 @@ func GetUserList(c *gin.Context) {
 
 	users, pagination, err := userService.GetUserList(c)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "获取用户列表失败", err)
+		listError(c, "获取用户列表失败", err)
 		return
 	}
 
@@ -113,6 +113,8 @@ func GetCurrentUserInfo(c *gin.Context) {
 		response.Error(c, http.StatusInternalServerError, "获取用户信息失败", err)
 		return
 	}
+	// 模拟登录时返回真实操作人，前端据此提示当前处于模拟登录状态
+	user.ImpersonatedBy = c.GetString("actorUsername")
 
 	response.Success(c, user, nil, "获取成功")
 }
@@ -139,3 +141,57 @@ func UpdateUserPassword(c *gin.Context) {
 
 	response.Success(c, nil, nil, "修改密码成功")
 }
+
+// UnlockUserLogin 解除指定用户的登录锁定
+func UnlockUserLogin(c *gin.Context) {
+	var userService service.UserService
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	user, err := userService.GetUserByID(uint(id))
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "用户不存在", err)
+		return
+	}
+
+	limiter := &service.LoginLimiter{Username: *user.Username, ClientIP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := limiter.Unlock(user.ID, c.GetString("username")); err != nil {
+		response.Error(c, http.StatusInternalServerError, "解锁失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "解锁成功")
+}
+
+// ImpersonateUser 模拟登录指定用户
+func ImpersonateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64) // 解析用户ID 10：表示10进制，64：表示64位
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	var impersonateRequest model.ImpersonateRequest
+	if err := c.ShouldBindJSON(&impersonateRequest); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err)
+		return
+	}
+
+	impersonationService := &service.ImpersonationService{
+		ActorID:       c.GetUint("userID"),
+		ActorUsername: c.GetString("username"),
+		ClientIP:      c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}
+	result, err := impersonationService.Impersonate(uint(id), &impersonateRequest)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "模拟登录失败", err)
+		return
+	}
+
+	response.Success(c, result, nil, "模拟登录成功")
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendVerifyCode 重新发送邮箱/手机号验证码
+func SendVerifyCode(c *gin.Context) {
+	var send service.SendVerifyCodeService
+
+	if err := c.ShouldBindJSON(&send); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := send.Send(); err != nil {
+		response.Error(c, http.StatusTooManyRequests, "发送验证码失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "验证码已发送")
+}
+
+// ConfirmVerifyCode 校验邮箱/手机号验证码
+func ConfirmVerifyCode(c *gin.Context) {
+	var confirm service.ConfirmVerifyCodeService
+
+	if err := c.ShouldBindJSON(&confirm); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := confirm.Confirm(); err != nil {
+		response.Error(c, http.StatusBadRequest, "验证失败", err)
+		return
+	}
+
+	response.Success(c, nil, nil, "验证成功")
+}
@@ -3,7 +3,6 @@ package middleware
 import (
 	"bytes"
 	"io"
-	"strings"
 	"time"
 
 	"ffly-baisc/internal/model"
@@ -22,7 +21,47 @@ func (w *responseBodyWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b) //  将数据写入原始的 ResponseWriter
 }
 
-// ApiLog 记录API访问日志
+// redactedBody 不保存的请求体/响应体在日志中的占位内容
+const redactedBody = "[已隐藏]"
+
+// sensitiveRoutes 请求或响应中包含密码、Token、验证码、密钥等敏感信息的路由（请求方法 + 路由模板）
+// 这些路由的日志不保存请求参数、请求体和响应体，新增此类接口时需要加到这里
+var sensitiveRoutes = map[string]bool{
+	// 注册、登录、刷新和注销 Token
+	"POST /api/v1/register":        true,
+	"POST /api/v1/login":           true,
+	"POST /api/v1/login/sms":       true,
+	"POST /api/v1/login/2fa":       true,
+	"POST /api/v1/login/2fa/setup": true,
+	"POST /api/v1/refresh":         true,
+	"POST /api/v1/logout":          true,
+	// 第三方登录回调（授权码）
+	"GET /api/v1/oidc/:provider/callback": true,
+	// OAuth2 授权服务器（客户端密钥、授权码、Token）
+	"POST /api/v1/oauth/token":              true,
+	"POST /api/v1/oauth/consent":            true,
+	"POST /api/v1/oauth/clients":            true,
+	"POST /api/v1/oauth/clients/:id/secret": true,
+	// 找回密码、邮箱/手机号验证
+	"POST /api/v1/password/reset": true,
+	"POST /api/v1/verify/confirm": true,
+	// 密码、两步验证、API Key、模拟登录
+	"POST /api/v1/user":                         true,
+	"PATCH /api/v1/user/:id/password":           true,
+	"POST /api/v1/user/info/2fa/setup":          true,
+	"POST /api/v1/user/info/2fa/enable":         true,
+	"POST /api/v1/user/info/2fa/disable":        true,
+	"POST /api/v1/user/info/2fa/recovery_codes": true,
+	"POST /api/v1/user/info/api_keys":           true,
+	"POST /api/v1/user/:id/impersonate":         true,
+}
+
+// isSensitiveRoute 是否不保存请求和响应内容，未匹配到路由的请求（404）同样不保存
+func isSensitiveRoute(c *gin.Context) bool {
+	return c.FullPath() == "" || sensitiveRoutes[c.Request.Method+" "+c.FullPath()]
+}
+
+// ApiLog 记录API访问日志，敏感路由不保存请求和响应内容
 func ApiLog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取请求体
@@ -51,27 +90,27 @@ func ApiLog() gin.HandlerFunc {
 		// 计算请求处理时间
 		duration := time.Since(startTime)
 
-		var typeStr string
-		if strings.Contains(c.Request.URL.Path, "/login") {
-			typeStr = "login"
-		} else {
-			typeStr = "operate"
+		query, body, responseBody := c.Request.URL.RawQuery, string(reqBodyBytes), responseBodyWriter.body.String()
+		if isSensitiveRoute(c) {
+			query, body, responseBody = "", redactedBody, redactedBody
 		}
 
 		// 创建日志记录
 		apiLog := &model.ApiLog{
-			UserID:       c.GetUint("userID"),
-			Username:     c.GetString("username"),
-			Method:       c.Request.Method,
-			Path:         c.Request.URL.Path,
-			Query:        c.Request.URL.RawQuery,
-			Body:         string(reqBodyBytes),
-			ResponseBody: responseBodyWriter.body.String(), // 获取响应体
-			ClientIP:     c.ClientIP(),
-			UserAgent:    c.Request.UserAgent(),
-			Type:         typeStr,
-			StatusCode:   c.Writer.Status(),
-			Duration:     duration.Milliseconds(), // 转换为毫秒
+			UserID:        c.GetUint("userID"),
+			Username:      c.GetString("username"),
+			ActorID:       c.GetUint("actorID"),
+			ActorUsername: c.GetString("actorUsername"),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			Query:         query,
+			Body:          body,
+			ResponseBody:  responseBody,
+			ClientIP:      c.ClientIP(),
+			UserAgent:     c.Request.UserAgent(),
+			Type:          "operate", // 登录日志由 service.LoginLimiter 按登录事件单独记录
+			StatusCode:    c.Writer.Status(),
+			Duration:      duration.Milliseconds(), // 转换为毫秒
 		}
 
 		// 异步保存日志
@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"ffly-baisc/internal/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "s3cr3t-Value-9f2c"
+
+// echoSecret 原样返回请求体，并在响应中附带敏感信息
+func echoSecret(c *gin.Context) {
+	body, _ := io.ReadAll(c.Request.Body)
+	c.JSON(http.StatusOK, gin.H{"request": string(body), "token": testSecret})
+}
+
+// serveLogged 发送请求并返回写入 api_logs 的全部参数
+func serveLogged(t *testing.T, method, route, target string) []string {
+	t.Helper()
+
+	mock, recorder := newMockMySQL(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `api_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r := newEngine()
+	r.Use(middleware.ApiLog())
+	r.Handle(method, route, echoSecret)
+
+	body := `{"password":"` + testSecret + `"}`
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(body)))
+	waitExpectations(t, mock)
+
+	return recorder.Values()
+}
+
+// TestApiLogRedactsSensitiveRoutes 敏感路由的请求参数、请求体和响应体不写入数据库
+func TestApiLogRedactsSensitiveRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		route  string
+		target string
+	}{
+		{"登录", "POST", "/api/v1/login", "/api/v1/login"},
+		{"刷新 Token", "POST", "/api/v1/refresh", "/api/v1/refresh"},
+		{"第三方登录回调", "GET", "/api/v1/oidc/:provider/callback", "/api/v1/oidc/github/callback?code=" + testSecret},
+		{"OAuth2 Token", "POST", "/api/v1/oauth/token", "/api/v1/oauth/token"},
+		{"OAuth2 客户端密钥", "POST", "/api/v1/oauth/clients/:id/secret", "/api/v1/oauth/clients/1/secret"},
+		{"重置密码", "POST", "/api/v1/password/reset", "/api/v1/password/reset"},
+		{"修改密码", "PATCH", "/api/v1/user/:id/password", "/api/v1/user/1/password"},
+		{"创建用户", "POST", "/api/v1/user", "/api/v1/user"},
+		{"两步验证", "POST", "/api/v1/user/info/2fa/enable", "/api/v1/user/info/2fa/enable"},
+		{"API Key", "POST", "/api/v1/user/info/api_keys", "/api/v1/user/info/api_keys"},
+		{"模拟登录", "POST", "/api/v1/user/:id/impersonate", "/api/v1/user/1/impersonate"},
+		{"未匹配的路由", "POST", "/api/v1/login", "/api/v1/logins"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := serveLogged(t, tt.method, tt.route, tt.target)
+			for _, value := range values {
+				if strings.Contains(value, testSecret) {
+					t.Errorf("敏感信息被写入日志: %s", value)
+				}
+			}
+		})
+	}
+}
+
+// TestApiLogKeepsBody 普通路由保存请求体和响应体
+func TestApiLogKeepsBody(t *testing.T) {
+	values := serveLogged(t, "POST", "/api/v1/role", "/api/v1/role")
+
+	var body, responseBody bool
+	for _, value := range values {
+		body = body || value == `{"password":"`+testSecret+`"}`
+		responseBody = responseBody || strings.Contains(value, `"token":"`+testSecret+`"`)
+	}
+	if !body || !responseBody {
+		t.Errorf("普通路由应保存请求体和响应体: %v", values)
+	}
+}
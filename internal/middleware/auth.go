@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"ffly-baisc/internal/service"
 	"ffly-baisc/pkg/auth"
 	"ffly-baisc/pkg/response"
 	"net/http"
@@ -9,7 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Auth JWT认证中间件
+// Auth 认证中间件，支持 JWT（Authorization: Bearer ...）和个人 API Key（Authorization: ApiKey ...）
 func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从 Header 中获取 token
@@ -22,6 +23,10 @@ func Auth() gin.HandlerFunc {
 
 		// 按空格分割
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			apiKeyAuth(c, parts[1])
+			return
+		}
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
 			response.Error(c, http.StatusUnauthorized, "请求头中 Authorization 格式有误", nil)
 			c.Abort()
@@ -43,9 +48,66 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		// 验证 Token 是否已被注销
+		var tokenService service.TokenService
+		if err := tokenService.ValidateAccessToken(claims, c.ClientIP()); err != nil {
+			response.Error(c, http.StatusUnauthorized, "Token 已失效", err)
+			c.Abort()
+			return
+		}
+
 		// 将当前请求的用户信息保存到请求的上下文中
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("tokenFamilyID", claims.FamilyID)
+		// 模拟登录时记录真实操作人
+		if claims.ActorID != 0 {
+			c.Set("actorID", claims.ActorID)
+			c.Set("actorUsername", claims.ActorUsername)
+		}
+		c.Next()
+	}
+}
+
+// apiKeyAuth API Key 认证，权限范围保存在上下文中，由 RequirePermission 校验
+func apiKeyAuth(c *gin.Context, key string) {
+	var apiKeyService service.ApiKeyService
+	apiKey, user, err := apiKeyService.Authenticate(key, c.ClientIP())
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "无效的 API Key", err)
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("username", *user.Username)
+	c.Set("apiKeyID", apiKey.ID)
+	c.Set("apiKeyScopes", apiKey.Scopes)
+	c.Next()
+}
+
+// DenyApiKey 禁止使用 API Key 访问，用于登录会话、两步验证、API Key 管理等账号安全相关接口
+func DenyApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("apiKeyID"); exists {
+			response.Error(c, http.StatusForbidden, "该接口不支持 API Key 访问", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyImpersonation 禁止模拟登录访问，用于修改密码、两步验证、API Key 管理等只能由用户本人操作的接口
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("actorID"); exists {
+			response.Error(c, http.StatusForbidden, "模拟登录不能访问该接口", nil)
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,84 @@
+package middleware_test
+
+import (
+	"database/sql/driver"
+	"ffly-baisc/internal/db"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// argRecorder 记录写入数据库的全部参数
+type argRecorder struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (r *argRecorder) ConvertValue(v any) (driver.Value, error) {
+	r.mu.Lock()
+	r.values = append(r.values, fmt.Sprint(v))
+	r.mu.Unlock()
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// Values 已记录的参数
+func (r *argRecorder) Values() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.values...)
+}
+
+// newMockMySQL 使用 sqlmock 替换 db.DB.MySQL，返回 mock 和记录 SQL 参数的 argRecorder
+func newMockMySQL(t *testing.T) (sqlmock.Sqlmock, *argRecorder) {
+	t.Helper()
+
+	recorder := &argRecorder{}
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp), sqlmock.ValueConverterOption(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := db.DB
+	db.DB = &db.DbSchema{MySQL: gormDB}
+	t.Cleanup(func() { db.DB = old })
+
+	return mock, recorder
+}
+
+// waitExpectations 等待异步执行的 SQL 满足预期
+func waitExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := mock.ExpectationsWereMet()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newEngine 测试用的 gin 引擎
+func newEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return gin.New()
+}
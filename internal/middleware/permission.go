@@ -8,36 +8,48 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RequirePermission 权限检查中间件
-// 使用方式：router.Use(RequirePermission("user:read", "user:write"))
-// func RequirePermission(permissions ...string) gin.HandlerFunc {
-// 	return func(c *gin.Context) {
-// 		// 获取用户ID
-// 		userID, exists := c.Get("userID")
-// 		if !exists {
-// 			response.Error(c, http.StatusUnauthorized, "用户未认证", nil)
-// 			c.Abort()
-// 			return
-// 		}
+// RequirePermission 权限检查中间件，用户拥有任意一个权限码即可访问
+// 使用方式：group.GET("", RequirePermission("user:list"), handler.GetUserList)
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 获取用户ID
+		userID, exists := c.Get("userID")
+		if !exists {
+			response.Error(c, http.StatusUnauthorized, "用户未认证", nil)
+			c.Abort()
+			return
+		}
+
+		// 使用 API Key 访问时，只能使用 Key 权限范围内的权限码
+		requiredPermissions := permissions
+		if scopes, exists := c.Get("apiKeyScopes"); exists {
+			var apiKeyService service.ApiKeyService
+			requiredPermissions = apiKeyService.FilterScopes(scopes.([]string), permissions)
+			if len(requiredPermissions) == 0 {
+				response.Error(c, http.StatusForbidden, "API Key 权限不足", nil)
+				c.Abort()
+				return
+			}
+		}
 
-// 		// 检查权限
-// 		var authService service.AuthPermissionService
-// 		hasPermission, err := authService.HasAnyPermission(userID.(uint), permissions)
-// 		if err != nil {
-// 			response.Error(c, http.StatusInternalServerError, "权限检查失败", err)
-// 			c.Abort()
-// 			return
-// 		}
+		// 检查权限
+		var authService service.AuthPermissionService
+		hasPermission, err := authService.HasAnyPermission(userID.(uint), requiredPermissions)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "权限检查失败", err)
+			c.Abort()
+			return
+		}
 
-// 		if !hasPermission {
-// 			response.Error(c, http.StatusForbidden, "权限不足", nil)
-// 			c.Abort()
-// 			return
-// 		}
+		if !hasPermission {
+			response.Error(c, http.StatusForbidden, "权限不足", nil)
+			c.Abort()
+			return
+		}
 
-// 		c.Next()
-// 	}
-// }
+		c.Next()
+	}
+}
 
 // RequireRole 角色检查中间件
 // 使用方式：router.Use(RequireRole(1, 2, 3))
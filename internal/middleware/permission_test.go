@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"ffly-baisc/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// expectUserPermissions 预期 HasAnyPermission 的查询：用户不是超级管理员，拥有 codes 中的权限码
+func expectUserPermissions(mock sqlmock.Sqlmock, codes ...string) {
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `roles` JOIN user_roles").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `user_roles` WHERE user_id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}).AddRow(1, 2))
+
+	rolePermissions := sqlmock.NewRows([]string{"role_id", "permission_id"})
+	for i := range codes {
+		rolePermissions.AddRow(2, i+1)
+	}
+	mock.ExpectQuery("SELECT \\* FROM `role_permissions` WHERE role_id IN").WillReturnRows(rolePermissions)
+	if len(codes) == 0 {
+		return
+	}
+
+	permissions := sqlmock.NewRows([]string{"id", "code"})
+	for i, code := range codes {
+		permissions.AddRow(i+1, code)
+	}
+	mock.ExpectQuery("SELECT \\* FROM `permissions` WHERE \\(id IN").WillReturnRows(permissions)
+}
+
+// TestRequirePermissionApiKeyScopes API Key 只能使用权限范围与创建者当前权限的交集
+func TestRequirePermissionApiKeyScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string // nil 表示使用登录 Token 访问
+		userCodes  []string // 创建者当前拥有的权限码，nil 表示不会查询权限
+		wantStatus int
+	}{
+		{"权限范围包含该权限", []string{"user:list"}, []string{"user:list"}, http.StatusOK},
+		{"权限范围外的接口", []string{"role:list"}, nil, http.StatusForbidden},
+		{"创建者已失去该权限", []string{"user:list"}, []string{}, http.StatusForbidden},
+		{"创建者只拥有权限范围外的权限", []string{"user:export"}, []string{"user:list"}, http.StatusForbidden},
+		{"登录 Token 不受权限范围限制", nil, []string{"user:list"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := newMockMySQL(t)
+			if tt.userCodes != nil {
+				expectUserPermissions(mock, tt.userCodes...)
+			}
+
+			engine := newEngine()
+			engine.Use(func(c *gin.Context) {
+				c.Set("userID", uint(1))
+				if tt.scopes != nil {
+					c.Set("apiKeyScopes", tt.scopes)
+				}
+			})
+			engine.GET("/user", middleware.RequirePermission("user:list", "user:export"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/user", nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d，期望 %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// ApiKey 个人 API Key 模型，只存储哈希值
+// 权限范围 Scopes 为创建者权限码的子集，使用时取与创建者当前权限的交集
+type ApiKey struct {
+	UserID     uint       `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Key 前缀，用于识别 Key，不能用于认证
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  *time.Time `json:"expiresAt"` // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIp"`
+	BaseModel
+}
+
+// TableName 自定义表名
+func (k *ApiKey) TableName() string {
+	return "api_keys"
+}
+
+// ApiKeyCreateRequest 创建 API Key 请求
+type ApiKeyCreateRequest struct {
+	Name      string     `json:"name" binding:"required,max=50"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"` // 权限码，必须为当前用户拥有的权限
+	ExpiresAt *time.Time `json:"expiresAt"`                                     // 过期时间，为空表示永不过期
+}
+
+// ApiKeyCreateResult 创建 API Key 结果，明文 Key 只返回这一次
+type ApiKeyCreateResult struct {
+	*ApiKey
+	Key string `json:"key"`
+}
@@ -12,10 +12,36 @@ type ApiLog struct {
 	StatusCode   int    `json:"statusCode"`
 	Duration     int64  `json:"duration"`
 	ResponseBody string `json:"responseBody"`
-	Type         string `json:"type"` // operate: 操作日志, login: 登录日志
+	Type         string `json:"type"`  // operate: 操作日志, login: 登录日志
+	Event        string `json:"event"` // 登录事件 login_success / login_failure / login_locked / login_blocked / login_unlock / impersonate
+	// 模拟登录时的真实操作人，UserID / Username 为被模拟的用户
+	ActorID       uint   `json:"actorId"`
+	ActorUsername string `json:"actorUsername"`
 	BaseModel
 }
 
+// FilterQueryFields 允许过滤的字段，请求体和响应体不允许过滤
+func (log *ApiLog) FilterQueryFields() []string {
+	return []string{"id", "user_id", "username", "method", "path", "user_agent", "client_ip", "status_code", "duration",
+		"type", "event", "actor_id", "actor_username", "created_at"}
+}
+
+// SortQueryFields 允许排序的字段
+func (log *ApiLog) SortQueryFields() []string {
+	return []string{"id", "user_id", "username", "method", "path", "client_ip", "status_code", "duration", "created_at"}
+}
+
+// DefaultSort 默认按主键倒序（即最新的日志在前）
+func (log *ApiLog) DefaultSort() string {
+	return "-id"
+}
+
+// SelectQueryFields 允许查询的字段，列表只显示部分字段时可以不查询请求体和响应体
+func (log *ApiLog) SelectQueryFields() []string {
+	return []string{"id", "user_id", "username", "method", "path", "query", "body", "user_agent", "client_ip", "status_code",
+		"duration", "response_body", "type", "event", "actor_id", "actor_username", "created_at", "updated_at"}
+}
+
 func (log *ApiLog) TableName() string {
 	return "api_logs"
 }
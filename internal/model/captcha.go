@@ -0,0 +1,8 @@
+package model
+
+// Captcha 图片验证码 -- 响应
+type Captcha struct {
+	CaptchaID string `json:"captchaId"` // 验证码ID，提交时与答案一起传回
+	Image     string `json:"image"`     // data:image/png;base64,... 可直接用于 <img src>
+	ExpiresIn int64  `json:"expiresIn"` // 有效期（秒）
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ImpersonateRequest 模拟登录请求模型 -- 请求入参
+type ImpersonateRequest struct {
+	Reason    string `json:"reason" binding:"required,max=255"`    // 模拟登录原因，记录到审计日志
+	ExpiresIn int    `json:"expiresIn" binding:"omitempty,min=60"` // 有效期（秒），超过系统配置的最长有效期时按最长有效期
+}
+
+// ImpersonationResult 模拟登录结果 -- 响应
+// 只返回 Access Token，到期后需要重新发起模拟登录
+type ImpersonationResult struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresIn   int64     `json:"expiresIn"` // Access Token 过期时间（秒）
+	ExpiresAt   time.Time `json:"expiresAt"`
+	SessionID   string    `json:"sessionId"` // 模拟登录会话ID，可通过登录设备接口注销
+	UserID      uint      `json:"userId"`
+	Username    string    `json:"username"`
+}
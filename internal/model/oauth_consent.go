@@ -0,0 +1,45 @@
+package model
+
+// OAuthConsent 用户对客户端的授权记录，已授权的 scope 再次申请时无需确认
+type OAuthConsent struct {
+	UserID   uint     `json:"userId"`
+	ClientID string   `json:"clientId"`
+	Scopes   []string `json:"scopes" gorm:"serializer:json"`
+	BaseModel
+}
+
+// TableName 自定义表名
+func (c *OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// OAuthAuthorizeRequest 授权请求参数（/oauth/authorize）
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// OAuthConsentRequest 用户确认授权请求 -- 请求入参
+type OAuthConsentRequest struct {
+	OAuthAuthorizeRequest
+	Approve bool `json:"approve"` // 是否同意授权
+}
+
+// OAuthConsentInfo 授权确认页信息 -- 响应
+type OAuthConsentInfo struct {
+	ClientID        string   `json:"clientId"`
+	ClientName      string   `json:"clientName"`
+	Scopes          []string `json:"scopes"`          // 本次申请的 scope
+	ConsentRequired bool     `json:"consentRequired"` // 是否需要用户确认，为 false 时前端可直接提交同意
+}
+
+// OAuthConsentResult 用户确认授权结果 -- 响应
+type OAuthConsentResult struct {
+	RedirectURI string `json:"redirectUri"` // 前端跳转地址，携带 code 或 error
+}
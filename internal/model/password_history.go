@@ -0,0 +1,13 @@
+package model
+
+// PasswordHistory 密码历史模型，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	UserID   uint   `json:"userId"`
+	Password string `json:"-"` // 密码哈希
+	BaseModel
+}
+
+// TableName 自定义表名
+func (p *PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -9,8 +9,8 @@ type Permission struct {
 	Title string `json:"title"` // 权限标题 --- menu
 	Name  string `json:"name"`
 	// Type      string        `json:"type"`      // 权限类型，数据库层面限制用户输入必须为 “menu / button"
-	Path string `json:"path"` // 路由路径 --- menu
-	// Code      string        `json:"code"`      // 权限码 --- button
+	Path      *string       `json:"path"`      // 路由路径 --- menu，按钮为 NULL
+	Code      *string       `json:"code"`      // 权限码 --- button，如 user:create，菜单为 NULL
 	Component string        `json:"component"` // 路由组件名称 --- menu
 	Redirect  string        `json:"redirect"`  // 重定向路径 --- menu
 	Visible   bool          `json:"visible"`   //
@@ -30,8 +30,8 @@ type PermissionCreatedRequest struct {
 	Title string `json:"title" binding:"required"` // 权限标题 --- menu
 	Name  string `json:"name"`
 	// Type      *string      `json:"type" binding:"required"` // 权限类型，数据库层面限制用户输入必须为 “menu / button"
-	Path string `json:"path"` // 路由路径 --- menu
-	// Code      *string      `json:"code"`                    // 权限码 --- button
+	Path      *string      `json:"path"`      // 路由路径 --- menu，为空时为按钮权限
+	Code      *string      `json:"code"`      // 权限码 --- button，如 user:create，按钮权限必填
 	Redirect  string       `json:"redirect"`  // 重定向路径 --- menu
 	Visible   bool         `json:"visible"`   //
 	Component string       `json:"component"` // 路由组件名称 --- menu
@@ -50,8 +50,8 @@ type PermissionPatchRequest struct {
 	Title string  `json:"title"` // 权限标题 --- menu
 	Name  *string `json:"name"`
 	// Type      *string      `json:"type"`      // 权限类型，数据库层面限制用户输入必须为 “menu / button"
-	Path *string `json:"path"` // 路由路径 --- menu
-	// Code      *string      `json:"code"`      // 权限码 --- button
+	Path      *string      `json:"path"`      // 路由路径 --- menu
+	Code      *string      `json:"code"`      // 权限码 --- button，如 user:create
 	Component *string      `json:"component"` // 路由组件名称 --- menu
 	Redirect  string       `json:"redirect"`  // 重定向路径 --- menu
 	Visible   bool         `json:"visible"`   // 1: 可见 2: 不可见 ---
@@ -65,6 +65,11 @@ type PermissionPatchRequest struct {
 	BaseModel
 }
 
+// FilterQueryFields 允许过滤的字段
+func (p *Permission) FilterQueryFields() []string {
+	return []string{"id", "title", "name", "path", "code", "component", "visible", "parent_id", "status", "created_at", "updated_at"}
+}
+
 // TableName 表名
 func (p *Permission) TableName() string {
 	return "permissions"
@@ -1,37 +1,68 @@
 package model
 
 import (
+	"ffly-baisc/pkg/query"
 	types "ffly-baisc/pkg/type"
 )
 
 // Role 角色模型 -- 只用于查询
 type Role struct {
-	Name          string       `json:"name"`
-	Code          string       `json:"code"`
-	Remark        string       `json:"remark"`
-	Status        types.Status `json:"status"`
-	PermissionIDs []uint       `json:"permissionIds,omitempty" gorm:"-"` // 权限ID列表，不存储在数据库中
+	Name             string       `json:"name"`
+	Code             string       `json:"code"`
+	Remark           string       `json:"remark"`
+	Status           types.Status `json:"status"`
+	RequireTwoFactor bool         `json:"requireTwoFactor"`                 // 拥有该角色的用户是否必须开启两步验证
+	PermissionIDs    []uint       `json:"permissionIds,omitempty" gorm:"-"` // 权限ID列表，不存储在数据库中
 	BaseModel
 }
 
 // RoleCreateRequest 创建角色请求模型 -- 请求入参
 type RoleCreateRequest struct {
-	Name   *string      `json:"name" binding:"required"`
-	Code   *string      `json:"code" binding:"required"`
-	Remark *string      `json:"remark"`
-	Status types.Status `json:"status" gorm:"default:1" binding:"omitempty,oneof=1 2"`
+	Name             *string      `json:"name" binding:"required"`
+	Code             *string      `json:"code" binding:"required"`
+	Remark           *string      `json:"remark"`
+	Status           types.Status `json:"status" gorm:"default:1" binding:"omitempty,oneof=1 2"`
+	RequireTwoFactor bool         `json:"requireTwoFactor"`
 	BaseModel
 }
 
 // RolePatchRequest 部分更新角色请求模型 -- 请求入参
 type RolePatchRequest struct {
-	Name   *string      `json:"name"`
-	Code   *string      `json:"code"`
-	Remark *string      `json:"remark"`
-	Status types.Status `json:"status"`
+	Name             *string      `json:"name"`
+	Code             *string      `json:"code"`
+	Remark           *string      `json:"remark"`
+	Status           types.Status `json:"status"`
+	RequireTwoFactor *bool        `json:"requireTwoFactor"`
 	BaseModel
 }
 
+// FilterQueryFields 允许过滤的字段
+func (r *Role) FilterQueryFields() []string {
+	return []string{"id", "name", "code", "remark", "status", "require_two_factor", "created_at", "updated_at"}
+}
+
+// FilterQueryRelations 允许过滤的关联，如 permissions.id EQ 42 查询拥有某个权限的角色
+func (r *Role) FilterQueryRelations() map[string]query.Relation {
+	return map[string]query.Relation{
+		"permissions": {Model: &Permission{}, JoinModel: &RolePermission{}, OwnerColumn: "role_id", TargetColumn: "permission_id"},
+	}
+}
+
+// SortQueryFields 允许排序的字段
+func (r *Role) SortQueryFields() []string {
+	return []string{"id", "name", "code", "status", "created_at", "updated_at"}
+}
+
+// DefaultSort 默认按创建顺序
+func (r *Role) DefaultSort() string {
+	return "id"
+}
+
+// SelectQueryFields 允许查询的字段
+func (r *Role) SelectQueryFields() []string {
+	return []string{"id", "name", "code", "remark", "status", "require_two_factor", "created_at", "updated_at"}
+}
+
 // TableName 自定义表名
 func (r *Role) TableName() string {
 	return "roles"
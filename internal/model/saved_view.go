@@ -0,0 +1,39 @@
+package model
+
+import "encoding/json"
+
+// SavedView 保存的列表视图：某个列表的查询条件、排序和显示字段，可以共享给某个角色
+type SavedView struct {
+	UserID   uint            `json:"userId"`                        // 创建者
+	Resource string          `json:"resource"`                      // 列表资源（表名）users / roles / oauth_clients / api_logs
+	Name     string          `json:"name"`                          // 视图名称
+	Params   json.RawMessage `json:"params"`                        // 查询条件，格式同列表的 params 参数
+	Sort     string          `json:"sort"`                          // 排序，格式同列表的 sort 参数，如 -created_at
+	Fields   []string        `json:"fields" gorm:"serializer:json"` // 显示字段，格式同列表的 fields 参数
+	RoleID   *uint           `json:"roleId"`                        // 共享给某个角色，为空时只有创建者可以使用
+	BaseModel
+}
+
+// SavedViewCreateRequest 创建列表视图请求 -- 请求入参
+type SavedViewCreateRequest struct {
+	Resource string          `json:"resource" binding:"required,oneof=users roles oauth_clients api_logs"`
+	Name     string          `json:"name" binding:"required,max=50"`
+	Params   json.RawMessage `json:"params"`
+	Sort     string          `json:"sort" binding:"max=255"`
+	Fields   []string        `json:"fields"`
+	RoleID   *uint           `json:"roleId"` // 共享给某个角色，只能共享给自己拥有的角色
+}
+
+// SavedViewPatchRequest 部分更新列表视图请求 -- 请求入参
+type SavedViewPatchRequest struct {
+	Name   *string         `json:"name" binding:"omitempty,max=50"`
+	Params json.RawMessage `json:"params"`
+	Sort   *string         `json:"sort" binding:"omitempty,max=255"`
+	Fields []string        `json:"fields"`
+	RoleID *uint           `json:"roleId"` // 为 0 时取消共享
+}
+
+// TableName 自定义表名
+func (v *SavedView) TableName() string {
+	return "saved_views"
+}
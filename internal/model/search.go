@@ -0,0 +1,11 @@
+package model
+
+// SearchResult 全局搜索结果
+type SearchResult struct {
+	Type     string `json:"type"`     // 结果类型 user / role / permission
+	ID       uint   `json:"id"`       // 数据ID
+	Title    string `json:"title"`    // 显示标题
+	Subtitle string `json:"subtitle"` // 补充信息，如用户名、角色编码
+	Field    string `json:"field"`    // 匹配度最高的字段
+	Score    int    `json:"score"`    // 相关度，越大越靠前
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Session 登录会话 -- 存储于 Redis，不落库
+type Session struct {
+	ID        string    `json:"id"` // 会话ID（令牌族ID）
+	UserID    uint      `json:"userId"`
+	ClientIP  string    `json:"clientIp"`
+	UserAgent string    `json:"userAgent"`
+	IssuedAt  time.Time `json:"issuedAt"` // 登录时间
+	LastSeen  time.Time `json:"lastSeen"` // 最后活跃时间
+	Current   bool      `json:"current"`  // 是否为当前请求所在的会话
+
+	ActorID       uint   `json:"actorId,omitempty"`       // 模拟登录会话的真实操作人ID
+	ActorUsername string `json:"actorUsername,omitempty"` // 模拟登录会话的真实操作人用户名
+}
@@ -1,42 +1,55 @@
 package model
 
 import (
+	"ffly-baisc/pkg/query"
 	types "ffly-baisc/pkg/type"
+	"time"
 )
 
 // User 用户模型 -- 查询 只用于查询
 type User struct {
-	Username  *string      `json:"username,omitempty"`
-	Password  *string      `json:"-"` // 不返回给前端, 但是也不从前端接收了
-	Nickname  *string      `json:"nickname,omitempty"`
-	Email     *string      `json:"email,omitempty"`
-	Phone     *string      `json:"phone,omitempty"`
-	Status    types.Status `json:"status,omitempty"`
-	Roles     []*Role      `json:"roles" binding:"omitempty" gorm:"-"` //  不存储在数据库中
-	BaseModel              // 嵌入基础模型
+	Username           *string      `json:"username,omitempty"`
+	Password           *string      `json:"-"` // 不返回给前端, 但是也不从前端接收了
+	Nickname           *string      `json:"nickname,omitempty"`
+	Email              *string      `json:"email,omitempty"`
+	Phone              *string      `json:"phone,omitempty"`
+	EmailVerified      bool         `json:"emailVerified"` // 邮箱是否已验证
+	PhoneVerified      bool         `json:"phoneVerified"` // 手机号是否已验证
+	Status             types.Status `json:"status,omitempty"`
+	PasswordChangedAt  *time.Time   `json:"passwordChangedAt,omitempty"`        // 密码修改时间
+	MustChangePassword bool         `json:"mustChangePassword"`                 // 下次登录时必须修改密码
+	TwoFactorEnabled   bool         `json:"twoFactorEnabled"`                   // 是否开启两步验证
+	TwoFactorSecret    *string      `json:"-"`                                  // 两步验证 TOTP 密钥，不返回给前端
+	AuthSource         string       `json:"authSource"`                         // 认证方式 local / ldap，为空时使用系统默认
+	Roles              []*Role      `json:"roles" binding:"omitempty" gorm:"-"` //  不存储在数据库中
+	ImpersonatedBy     string       `json:"impersonatedBy,omitempty" gorm:"-"`  // 模拟登录的真实操作人，只用于当前用户信息
+	BaseModel                       // 嵌入基础模型
 }
 
 // UserCreateRequest 用户创建请求模型 -- 请求入参
 type UserCreateRequest struct {
-	Username  *string      `json:"username" binding:"required,min=3,max=50"`
-	Password  *string      `json:"password" binding:"required"`
-	Nickname  *string      `json:"nickname" binding:"omitempty,min=2,max=50"`
-	Email     *string      `json:"email" binding:"omitempty,email"`
-	Phone     *string      `json:"phone" binding:"omitempty"`
-	Status    types.Status `json:"status" gorm:"default:1" binding:"omitempty,oneof=1 2"` // 使用指针以区分是否需要更新
-	RoleIDs   []uint       `json:"roleIds" binding:"omitempty" gorm:"-"`
-	BaseModel              // 嵌入基础模型
+	Username   *string      `json:"username" binding:"required,min=3,max=50"`
+	Password   *string      `json:"password" binding:"required"`
+	Nickname   *string      `json:"nickname" binding:"omitempty,min=2,max=50"`
+	Email      *string      `json:"email" binding:"omitempty,email"`
+	Phone      *string      `json:"phone" binding:"omitempty"`
+	Status     types.Status `json:"status" gorm:"default:1" binding:"omitempty,oneof=1 2"` // 使用指针以区分是否需要更新
+	AuthSource string       `json:"authSource" binding:"omitempty,oneof=local ldap"`       // 认证方式，为空时使用系统默认
+	RoleIDs    []uint       `json:"roleIds" binding:"omitempty" gorm:"-"`
+	BaseModel               // 嵌入基础模型
 }
 
 // UserPatchRequest 用户更新请求模型 -- 部分更新 请求入参
 type UserPatchRequest struct {
-	Username  *string      `json:"username" binding:"omitempty,min=3,max=50"`
-	Nickname  *string      `json:"nickname" binding:"omitempty,min=2,max=50"`
-	Email     *string      `json:"email" binding:"omitempty,email"`
-	Phone     *string      `json:"phone" binding:"omitempty"`
-	Status    types.Status `json:"status" binding:"omitempty,oneof=1 2"` // 使用指针以区分是否需要更新
-	RoleIDs   []uint       `json:"roleIds" binding:"omitempty" gorm:"-"`
-	BaseModel              // 嵌入基础模型
+	Username           *string      `json:"username" binding:"omitempty,min=3,max=50"`
+	Nickname           *string      `json:"nickname" binding:"omitempty,min=2,max=50"`
+	Email              *string      `json:"email" binding:"omitempty,email"`
+	Phone              *string      `json:"phone" binding:"omitempty"`
+	Status             types.Status `json:"status" binding:"omitempty,oneof=1 2"` // 使用指针以区分是否需要更新
+	RoleIDs            []uint       `json:"roleIds" binding:"omitempty" gorm:"-"`
+	MustChangePassword *bool        `json:"mustChangePassword"`                              // 要求用户下次登录时修改密码
+	AuthSource         *string      `json:"authSource" binding:"omitempty,oneof=local ldap"` // 认证方式 local / ldap
+	BaseModel                       // 嵌入基础模型
 }
 
 // UpdatePasswordRequest 更新密码请求模型 -- 更新密码 请求入参
@@ -47,11 +60,56 @@ type UpdatePasswordRequest struct {
 	BaseModel               // 嵌入基础模型
 }
 
+// TwoFactorCodeRequest 两步验证码请求模型 -- 请求入参
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // TOTP 验证码或恢复码
+}
+
+// TwoFactorSetup 两步验证绑定信息 -- 响应
+type TwoFactorSetup struct {
+	Secret string `json:"secret"` // TOTP 密钥，用于手动输入
+	URI    string `json:"uri"`    // otpauth:// 链接，前端据此生成二维码
+}
+
 // SimpleQueryFields 简单查询器
 func (u *User) SimpleQueryFields() []string {
 	return []string{"id", "username"}
 }
 
+// FilterQueryFields 允许过滤的字段，不包含密码、两步验证密钥等敏感字段
+func (u *User) FilterQueryFields() []string {
+	return []string{"id", "username", "nickname", "email", "phone", "email_verified", "phone_verified", "status",
+		"must_change_password", "two_factor_enabled", "auth_source", "created_at", "updated_at"}
+}
+
+// FilterQueryRelations 允许过滤的关联，如 roles.code EQ ops 查询拥有某个角色的用户
+func (u *User) FilterQueryRelations() map[string]query.Relation {
+	return map[string]query.Relation{
+		"roles": {Model: &Role{}, JoinModel: &UserRole{}, OwnerColumn: "user_id", TargetColumn: "role_id"},
+	}
+}
+
+// SortQueryFields 允许排序的字段
+func (u *User) SortQueryFields() []string {
+	return []string{"id", "username", "nickname", "email", "phone", "status", "created_at", "updated_at"}
+}
+
+// DefaultSort 默认按创建时间倒序
+func (u *User) DefaultSort() string {
+	return "-created_at"
+}
+
+// SelectQueryFields 允许查询的字段，不包含密码、两步验证密钥等敏感字段
+func (u *User) SelectQueryFields() []string {
+	return []string{"id", "username", "nickname", "email", "phone", "email_verified", "phone_verified", "status",
+		"password_changed_at", "must_change_password", "two_factor_enabled", "auth_source", "created_at", "updated_at"}
+}
+
+// ExpandQueryFields 允许展开的关联
+func (u *User) ExpandQueryFields() []string {
+	return []string{"roles"}
+}
+
 // TableName 自定义表名
 func (u *User) TableName() string {
 	return "users"
@@ -0,0 +1,22 @@
+package model
+
+// UserIdentity 外部身份（OIDC）与本系统用户的绑定关系
+type UserIdentity struct {
+	UserID   uint   `json:"userId"`
+	Provider string `json:"provider"` // 身份提供方标识
+	Subject  string `json:"subject"`  // 身份提供方中的用户唯一标识（sub）
+	Email    string `json:"email"`
+	BaseModel
+}
+
+// TableName 自定义表名
+func (i *UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCProvider 身份提供方信息 -- 响应
+type OIDCProvider struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	LoginURL string `json:"loginUrl"`
+}
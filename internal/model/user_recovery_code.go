@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// UserRecoveryCode 两步验证恢复码模型，只存储哈希值
+type UserRecoveryCode struct {
+	UserID   uint       `json:"userId"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"usedAt"` // 使用时间，为空表示未使用
+	BaseModel
+}
+
+// TableName 自定义表名
+func (r *UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
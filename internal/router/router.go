@@ -16,18 +16,25 @@ func InitRouter() {
 	// 使用 ApiLog 中间件
 	r.Use(middleware.ApiLog())
 
+	// 注册 /.well-known 路由（JWKS 等标准端点）
+	routes.ResigterWellKnownRouter(&r.RouterGroup)
+
 	// API v1
 	v1 := r.Group("/api/v1")
 	{
-		// --------------------
-		// 注册 API 日志 路由
-		routes.ResigterApiLogRouter(v1)
-
 		// --------------------
 		// 公开路由
 		public := v1.Group("")
 		// 注册登录路由
 		routes.ResigterLoginRouter(public)
+		// 注册 OpenID Connect 登录路由
+		routes.ResigterOIDCRouter(public)
+		// 注册 OAuth2 / OpenID Connect 授权服务器路由
+		routes.ResigterOAuthRouter(public)
+		// 注册找回密码路由
+		routes.ResigterPasswordRouter(public)
+		// 注册邮箱/手机号验证路由
+		routes.ResigterVerifyRouter(public)
 
 		// --------------------
 		// 需要认证的路由
@@ -35,12 +42,24 @@ func InitRouter() {
 		// 注册中间件
 		authGroup.Use(middleware.Auth())
 
+		// 注册退出登录路由
+		routes.ResigterLogoutRouter(authGroup)
 		// 注册用户路由
 		routes.ResigterUserRouter(authGroup)
 		// 注册角色路由
 		routes.ResigterRoleRouter(authGroup)
 		// 注册权限路由
 		routes.ResigterPermissionRouter(authGroup)
+		// 注册 API 日志 路由
+		routes.ResigterApiLogRouter(authGroup)
+		// 注册 OAuth2 授权确认和客户端管理路由
+		routes.ResigterOAuthConsentRouter(authGroup)
+		// 注册列表查询路由
+		routes.ResigterQueryRouter(authGroup)
+		// 注册列表视图路由
+		routes.ResigterSavedViewRouter(authGroup)
+		// 注册全局搜索路由
+		routes.ResigterSearchRouter(authGroup)
 	}
 
 	r.Run(fmt.Sprintf(":%d", config.GlobalConfig.App.Port)) // 监听端口
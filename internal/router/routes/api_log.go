@@ -2,6 +2,7 @@ package routes
 
 import (
 	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,6 +10,6 @@ import (
 func ResigterApiLogRouter(g *gin.RouterGroup) {
 	group := g.Group("/api_log")
 	{
-		group.GET("", handler.GetApiLogList)
+		group.GET("", middleware.RequirePermission("api_log:list"), handler.GetApiLogList)
 	}
 }
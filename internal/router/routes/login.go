@@ -2,16 +2,31 @@ package routes
 
 import (
 	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
 // 注册路由
 func ResigterLoginRouter(group *gin.RouterGroup) {
+	// 图片验证码
+	group.GET("/captcha", handler.GetCaptcha)
 	// 用户注册
 	group.POST("/register", handler.Register)
 	// 用户登录
 	group.POST("/login", handler.Login)
+	// 短信验证码登录
+	group.POST("/login/sms/code", handler.SendLoginSMSCode)
+	group.POST("/login/sms", handler.LoginSMS)
+	// 两步验证登录
+	group.POST("/login/2fa", handler.LoginTwoFactor)
+	group.POST("/login/2fa/setup", handler.LoginTwoFactorSetup)
 	// 刷新 Token
 	group.POST("/refresh", handler.RefreshToken)
 }
+
+// 注册需要认证的登录相关路由
+func ResigterLogoutRouter(group *gin.RouterGroup) {
+	// 退出登录
+	group.POST("/logout", middleware.DenyApiKey(), handler.Logout)
+}
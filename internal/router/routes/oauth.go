@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注册 OAuth2 / OpenID Connect 授权服务器公开路由
+func ResigterOAuthRouter(g *gin.RouterGroup) {
+	group := g.Group("/oauth")
+	{
+		// 授权端点，跳转到前端授权确认页面
+		group.GET("/authorize", handler.OAuthAuthorize)
+		// 令牌端点
+		group.POST("/token", handler.OAuthToken)
+		// 用户信息端点
+		group.GET("/userinfo", handler.OAuthUserinfo)
+		group.POST("/userinfo", handler.OAuthUserinfo)
+	}
+}
+
+// 注册 OAuth2 授权确认和客户端管理路由（需要认证）
+func ResigterOAuthConsentRouter(g *gin.RouterGroup) {
+	group := g.Group("/oauth")
+	{
+		// 授权确认
+		group.GET("/consent", middleware.DenyApiKey(), handler.GetOAuthConsent)
+		group.POST("/consent", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.OAuthConsent)
+
+		// 客户端管理
+		group.GET("/clients", middleware.RequirePermission("oauth_client:list"), handler.GetOAuthClientList)
+		group.GET("/clients/:id", middleware.RequirePermission("oauth_client:detail"), handler.GetOAuthClient)
+		group.POST("/clients", middleware.RequirePermission("oauth_client:create"), handler.CreateOAuthClient)
+		group.PATCH("/clients/:id", middleware.RequirePermission("oauth_client:update"), handler.PatchOAuthClient)
+		group.POST("/clients/:id/secret", middleware.RequirePermission("oauth_client:update"), handler.ResetOAuthClientSecret)
+		group.DELETE("/clients/:id", middleware.RequirePermission("oauth_client:delete"), handler.DeleteOAuthClient)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注册 OpenID Connect 登录路由
+func ResigterOIDCRouter(g *gin.RouterGroup) {
+	group := g.Group("/oidc")
+	{
+		// 身份提供方列表
+		group.GET("/providers", handler.GetOIDCProviders)
+		// 跳转到身份提供方登录
+		group.GET("/:provider/login", handler.OIDCLogin)
+		// 身份提供方回调
+		group.GET("/:provider/callback", handler.OIDCCallback)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注册找回密码路由
+func ResigterPasswordRouter(g *gin.RouterGroup) {
+	group := g.Group("/password")
+	{
+		// 忘记密码，发送重置邮件
+		group.POST("/forgot", handler.ForgotPassword)
+		// 重置密码
+		group.POST("/reset", handler.ResetPassword)
+	}
+}
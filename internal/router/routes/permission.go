@@ -2,6 +2,7 @@ package routes
 
 import (
 	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,13 +10,17 @@ import (
 func ResigterPermissionRouter(g *gin.RouterGroup) {
 	group := g.Group("/permission")
 	{
-		group.GET("", handler.GetPermissionList)
-		group.GET("/:id", handler.GetPermission)
-		group.POST("", handler.CreatePermission)
-		group.PUT("", handler.PutPermission)
-		group.PATCH("/:id", handler.PatchPermission)
-		group.DELETE("/:id", handler.DeletePermission)
-		group.GET("/export", handler.ExportPermission)
-		group.GET("/current_user", handler.GetCurrentUserPermission)
+		// 当前用户的菜单和权限码，登录即可访问
+		// 菜单不支持 API Key 访问，权限码只返回 API Key 权限范围内的
+		group.GET("/current_user", middleware.DenyApiKey(), handler.GetCurrentUserPermission)
+		group.GET("/current_user/codes", handler.GetCurrentUserPermissionCodes)
+
+		group.GET("", middleware.RequirePermission("permission:list"), handler.GetPermissionList)
+		group.GET("/export", middleware.RequirePermission("permission:export"), handler.ExportPermission)
+		group.GET("/:id", middleware.RequirePermission("permission:detail"), handler.GetPermission)
+		group.POST("", middleware.RequirePermission("permission:create"), handler.CreatePermission)
+		group.PUT("", middleware.RequirePermission("permission:update"), handler.PutPermission)
+		group.PATCH("/:id", middleware.RequirePermission("permission:update"), handler.PatchPermission)
+		group.DELETE("/:id", middleware.RequirePermission("permission:delete"), handler.DeletePermission)
 	}
 }
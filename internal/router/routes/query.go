@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResigterQueryRouter 注册列表查询相关路由
+func ResigterQueryRouter(g *gin.RouterGroup) {
+	group := g.Group("/query")
+	{
+		// 静态的 JSON Schema，不包含用户数据，API Key 也可以访问
+		group.GET("/schema", handler.GetSearchParamsSchema)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,12 +10,12 @@ import (
 func ResigterRoleRouter(g *gin.RouterGroup) {
 	group := g.Group("/role")
 	{
-		group.GET("", handler.GetRoleList)
-		group.GET("/:id", handler.GetRole)
-		group.POST("", handler.CreateRole)
-		group.PATCH("/:id", handler.PatchRole)
+		group.GET("", middleware.RequirePermission("role:list"), handler.GetRoleList)
+		group.GET("/:id", middleware.RequirePermission("role:detail"), handler.GetRole)
+		group.POST("", middleware.RequirePermission("role:create"), handler.CreateRole)
+		group.PATCH("/:id", middleware.RequirePermission("role:update"), handler.PatchRole)
 		// 更新角色权限
-		group.PATCH("/:id/permissions", handler.PatchRolePermissions)
-		group.DELETE("/:id", handler.DeleteRole)
+		group.PATCH("/:id/permissions", middleware.RequirePermission("role:assign"), handler.PatchRolePermissions)
+		group.DELETE("/:id", middleware.RequirePermission("role:delete"), handler.DeleteRole)
 	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResigterSavedViewRouter 注册列表视图路由，视图属于当前用户，不需要权限码
+// 没有权限码可供 API Key 权限范围校验，API Key 只能查看视图，不能修改
+func ResigterSavedViewRouter(g *gin.RouterGroup) {
+	group := g.Group("/saved_view")
+	{
+		group.GET("", handler.GetSavedViews)
+		group.POST("", middleware.DenyApiKey(), handler.CreateSavedView)
+		group.PATCH("/:id", middleware.DenyApiKey(), handler.PatchSavedView)
+		group.DELETE("/:id", middleware.DenyApiKey(), handler.DeleteSavedView)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResigterSearchRouter 注册全局搜索路由，按当前用户的列表权限过滤搜索结果，不需要单独的权限码
+func ResigterSearchRouter(g *gin.RouterGroup) {
+	group := g.Group("/search")
+	{
+		group.GET("", handler.Search)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"ffly-baisc/internal/handler"
+	"ffly-baisc/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,14 +11,38 @@ func ResigterUserRouter(g *gin.RouterGroup) {
 	group := g.Group("/user")
 	{
 		// 如果要这样写，那么 /info 就必须在 /:id 之前，否则会匹配到 /:id 路由
-		group.GET("/info", handler.GetCurrentUserInfo)
-		// 修改密码
-		group.PATCH("/:id/password", handler.UpdateUserPassword)
+		// 当前用户信息没有权限码可供 API Key 权限范围校验，不支持 API Key 访问
+		group.GET("/info", middleware.DenyApiKey(), handler.GetCurrentUserInfo)
+		// 当前用户的登录设备，模拟登录时不允许注销被模拟用户的设备
+		group.GET("/info/sessions", middleware.DenyApiKey(), handler.GetCurrentUserSessions)
+		group.DELETE("/info/sessions", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.DeleteCurrentUserOtherSessions)
+		group.DELETE("/info/sessions/:sessionId", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.DeleteCurrentUserSession)
+		// 当前用户的两步验证
+		group.POST("/info/2fa/setup", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.SetupCurrentUserTwoFactor)
+		group.POST("/info/2fa/enable", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.EnableCurrentUserTwoFactor)
+		group.POST("/info/2fa/disable", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.DisableCurrentUserTwoFactor)
+		group.POST("/info/2fa/recovery_codes", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.RegenerateCurrentUserRecoveryCodes)
+		// 当前用户的 API Key
+		group.GET("/info/api_keys", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.GetCurrentUserApiKeys)
+		group.POST("/info/api_keys", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.CreateCurrentUserApiKey)
+		group.DELETE("/info/api_keys/:keyId", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.DeleteCurrentUserApiKey)
+		// 修改密码（需要校验旧密码）
+		group.PATCH("/:id/password", middleware.DenyApiKey(), middleware.DenyImpersonation(), handler.UpdateUserPassword)
 
-		group.GET("", handler.GetUserList)
-		group.GET("/:id", handler.GetUser)
-		group.POST("", handler.CreateUser)
-		group.PATCH("/:id", handler.PatchUser)
-		group.DELETE("/:id", handler.DeleteUser)
+		group.GET("", middleware.RequirePermission("user:list"), handler.GetUserList)
+		group.GET("/:id", middleware.RequirePermission("user:detail"), handler.GetUser)
+		group.POST("", middleware.RequirePermission("user:create"), handler.CreateUser)
+		group.PATCH("/:id", middleware.RequirePermission("user:update"), handler.PatchUser)
+		group.DELETE("/:id", middleware.RequirePermission("user:delete"), handler.DeleteUser)
+		// 指定用户的登录设备
+		group.GET("/:id/sessions", middleware.RequirePermission("user:session"), handler.GetUserSessions)
+		group.DELETE("/:id/sessions", middleware.RequirePermission("user:session"), handler.DeleteUserSessions)
+		group.DELETE("/:id/sessions/:sessionId", middleware.RequirePermission("user:session"), handler.DeleteUserSession)
+		// 重置指定用户的两步验证
+		group.DELETE("/:id/2fa", middleware.RequirePermission("user:reset_2fa"), handler.ResetUserTwoFactor)
+		// 解除指定用户的登录锁定
+		group.POST("/:id/unlock", middleware.RequirePermission("user:unlock"), handler.UnlockUserLogin)
+		// 模拟登录指定用户
+		group.POST("/:id/impersonate", middleware.DenyApiKey(), middleware.DenyImpersonation(), middleware.RequirePermission("user:impersonate"), handler.ImpersonateUser)
 	}
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注册邮箱/手机号验证路由
+func ResigterVerifyRouter(g *gin.RouterGroup) {
+	group := g.Group("/verify")
+	{
+		// 重新发送验证码
+		group.POST("/send", handler.SendVerifyCode)
+		// 校验验证码
+		group.POST("/confirm", handler.ConfirmVerifyCode)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"ffly-baisc/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResigterWellKnownRouter 注册 /.well-known 公开路由
+func ResigterWellKnownRouter(g *gin.RouterGroup) {
+	group := g.Group("/.well-known")
+	{
+		group.GET("/jwks.json", handler.GetJWKS)
+		group.GET("/openid-configuration", handler.GetOpenIDConfiguration)
+	}
+}
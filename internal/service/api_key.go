@@ -0,0 +1,160 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	types "ffly-baisc/pkg/type"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix        = "ffly_"     // API Key 固定前缀，便于密钥扫描工具识别
+	apiKeyTouchInterval = time.Minute // 最近使用时间的更新间隔，避免每次请求都写库
+)
+
+// ApiKeyService 个人 API Key 服务
+type ApiKeyService struct{}
+
+// GetUserApiKeys 获取用户的 API Key 列表
+func (service *ApiKeyService) GetUserApiKeys(userID uint) ([]*model.ApiKey, error) {
+	var apiKeys []*model.ApiKey
+	if err := db.DB.MySQL.Where("user_id = ?", userID).Order("id DESC").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("查询 API Key 失败: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// CreateApiKey 创建 API Key，权限范围必须是用户当前权限的子集
+func (service *ApiKeyService) CreateApiKey(userID uint, apiKeyCreateRequest *model.ApiKeyCreateRequest) (*model.ApiKeyCreateResult, error) {
+	if apiKeyCreateRequest.ExpiresAt != nil && !apiKeyCreateRequest.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("过期时间必须晚于当前时间")
+	}
+
+	// 校验权限范围
+	var authService AuthPermissionService
+	codes, err := authService.GetUserPermissionCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	codeMap := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		codeMap[code] = true
+	}
+
+	scopes := make([]string, 0, len(apiKeyCreateRequest.Scopes))
+	scopeMap := make(map[string]bool, len(apiKeyCreateRequest.Scopes))
+	for _, scope := range apiKeyCreateRequest.Scopes {
+		if !codeMap[scope] {
+			return nil, fmt.Errorf("没有权限: %s", scope)
+		}
+		if !scopeMap[scope] {
+			scopeMap[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	// 生成 Key：ffly_<8 位前缀>_<随机串>
+	b := make([]byte, 28)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("生成 API Key 失败: %w", err)
+	}
+	random := hex.EncodeToString(b)
+	key := apiKeyPrefix + random[:8] + "_" + random[8:]
+
+	apiKey := &model.ApiKey{
+		UserID:    userID,
+		Name:      apiKeyCreateRequest.Name,
+		Prefix:    apiKeyPrefix + random[:8],
+		KeyHash:   hashApiKey(key),
+		Scopes:    scopes,
+		ExpiresAt: apiKeyCreateRequest.ExpiresAt,
+	}
+	if err := db.DB.MySQL.Create(apiKey).Error; err != nil {
+		return nil, fmt.Errorf("创建 API Key 失败: %w", err)
+	}
+
+	return &model.ApiKeyCreateResult{ApiKey: apiKey, Key: key}, nil
+}
+
+// DeleteApiKey 吊销用户的 API Key
+func (service *ApiKeyService) DeleteApiKey(userID, id uint) error {
+	result := db.DB.MySQL.Where("id = ? AND user_id = ?", id, userID).Delete(&model.ApiKey{})
+	if result.Error != nil {
+		return fmt.Errorf("吊销 API Key 失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("API Key 不存在")
+	}
+
+	return nil
+}
+
+// Authenticate 校验 API Key，返回 Key 及其所属用户，并记录最近使用时间
+func (service *ApiKeyService) Authenticate(key, clientIP string) (*model.ApiKey, *model.User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, errors.New("API Key 格式错误")
+	}
+
+	var apiKey model.ApiKey
+	if err := db.DB.MySQL.Where("key_hash = ?", hashApiKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("API Key 无效或已吊销")
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(now) {
+		return nil, nil, errors.New("API Key 已过期")
+	}
+
+	var user model.User
+	if err := db.DB.MySQL.First(&user, apiKey.UserID).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.Status != types.StatusEnabled {
+		return nil, nil, errors.New("用户已被禁用")
+	}
+
+	// 记录最近使用时间
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval || apiKey.LastUsedIP != clientIP {
+		db.DB.MySQL.Model(&model.ApiKey{}).Where("id = ?", apiKey.ID).Updates(map[string]any{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		})
+	}
+
+	return &apiKey, &user, nil
+}
+
+// FilterScopes 过滤出 API Key 权限范围内的权限码
+func (service *ApiKeyService) FilterScopes(scopes, codes []string) []string {
+	scopeMap := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scopeMap[scope] = true
+	}
+
+	filtered := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if scopeMap[code] {
+			filtered = append(filtered, code)
+		}
+	}
+
+	return filtered
+}
+
+// hashApiKey 计算 API Key 哈希，API Key 为高熵随机值，使用 SHA-256 即可
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
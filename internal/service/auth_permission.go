@@ -60,41 +60,91 @@ func (s *AuthPermissionService) GetUserPermissions(userID uint) ([]*model.Permis
 	return permissions, nil
 }
 
-// // HasPermission 检查用户是否有指定权限
-// func (s *AuthPermissionService) HasPermission(userID uint, permissionPath string) (bool, error) {
-// 	userPermissions, err := s.GetUserPermissions(userID)
-// 	if err != nil {
-// 		return false, err
-// 	}
-
-// 	for _, permission := range userPermissions {
-// 		if permission == permissionPath {
-// 			return true, nil
-// 		}
-// 	}
-
-// 	return false, nil
-// }
-
-// // HasAnyPermission 检查用户是否有任意一个权限
-// func (s *AuthPermissionService) HasAnyPermission(userID uint, permissionPaths []string) (bool, error) {
-// 	userPermissions, err := s.GetUserPermissions(userID)
-// 	if err != nil {
-// 		return false, err
-// 	}
-
-// 	// 将用户权限转换为map，提高查找效率
-// 	permissionMap := make(map[string]bool)
-// 	for _, permission := range userPermissions {
-// 		permissionMap[permission] = true
-// 	}
-
-// 	// 检查是否有任意一个权限
-// 	for _, permissionPath := range permissionPaths {
-// 		if permissionMap[permissionPath] {
-// 			return true, nil
-// 		}
-// 	}
-
-// 	return false, nil
-// }
+// SuperAdminRoleCode 超级管理员角色编码，拥有该角色的用户跳过权限码校验
+const SuperAdminRoleCode = "admin"
+
+// IsSuperAdmin 判断用户是否为超级管理员
+func (s *AuthPermissionService) IsSuperAdmin(userID uint) (bool, error) {
+	var count int64
+	if err := db.DB.MySQL.Model(&model.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id AND user_roles.deleted_at IS NULL").
+		Where("user_roles.user_id = ? AND roles.code = ? AND roles.status = 1", userID, SuperAdminRoleCode).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetUserPermissionCodes 根据用户ID获取用户权限码列表，超级管理员返回所有启用的权限码
+func (s *AuthPermissionService) GetUserPermissionCodes(userID uint) ([]string, error) {
+	isSuperAdmin, err := s.IsSuperAdmin(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var userPermissions []*model.Permission
+	if isSuperAdmin {
+		if err := db.DB.MySQL.Where("status = 1").Find(&userPermissions).Error; err != nil {
+			return nil, fmt.Errorf("查询权限详情失败: %w", err)
+		}
+	} else {
+		userPermissions, err = s.GetUserPermissions(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	codes := make([]string, 0, len(userPermissions))
+	for _, permission := range userPermissions {
+		if permission.Code != nil {
+			codes = append(codes, *permission.Code)
+		}
+	}
+
+	return codes, nil
+}
+
+// HasPermission 检查用户是否有指定权限
+func (s *AuthPermissionService) HasPermission(userID uint, permissionCode string) (bool, error) {
+	return s.HasAnyPermission(userID, []string{permissionCode})
+}
+
+// HasAnyPermission 检查用户是否有任意一个权限
+func (s *AuthPermissionService) HasAnyPermission(userID uint, permissionCodes []string) (bool, error) {
+	// 没有声明权限码，则不做限制
+	if len(permissionCodes) == 0 {
+		return true, nil
+	}
+
+	// 超级管理员拥有所有权限
+	isSuperAdmin, err := s.IsSuperAdmin(userID)
+	if err != nil {
+		return false, err
+	}
+	if isSuperAdmin {
+		return true, nil
+	}
+
+	userPermissions, err := s.GetUserPermissions(userID)
+	if err != nil {
+		return false, err
+	}
+
+	// 将用户权限转换为map，提高查找效率
+	permissionMap := make(map[string]bool, len(userPermissions))
+	for _, permission := range userPermissions {
+		if permission.Code != nil {
+			permissionMap[*permission.Code] = true
+		}
+	}
+
+	// 检查是否有任意一个权限
+	for _, permissionCode := range permissionCodes {
+		if permissionMap[permissionCode] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
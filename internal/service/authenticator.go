@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/utils"
+	"fmt"
+)
+
+const (
+	AuthSourceLocal = "local" // 本地密码（bcrypt）
+	AuthSourceLDAP  = "ldap"  // LDAP / Active Directory
+)
+
+var (
+	ErrUserNotFound  = errors.New("用户名不存在")
+	ErrWrongPassword = errors.New("密码错误")
+
+	errDirectoryPassword = errors.New("目录用户的密码由 LDAP 管理，请在目录服务中修改")
+)
+
+// Authenticator 登录认证器
+// user 为本地用户，本地不存在时为 nil；认证通过后返回本地用户（目录用户首次登录时可能是新建的）
+// 凭证错误返回 ErrUserNotFound / ErrWrongPassword，计入登录失败次数
+type Authenticator interface {
+	Authenticate(user *model.User, username, password string) (*model.User, error)
+}
+
+// GetAuthenticator 根据认证方式获取认证器
+func GetAuthenticator(source string) (Authenticator, error) {
+	switch source {
+	case AuthSourceLocal:
+		return &PasswordAuthenticator{}, nil
+	case AuthSourceLDAP:
+		return &LDAPAuthenticator{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的认证方式: %s", source)
+	}
+}
+
+// AuthSourceOf 获取用户的认证方式，用户未指定（或 user 为 nil）时使用系统默认
+func AuthSourceOf(user *model.User) string {
+	if user != nil && user.AuthSource != "" {
+		return user.AuthSource
+	}
+	if source := config.GlobalConfig.App.AuthSource; source != "" {
+		return source
+	}
+	return AuthSourceLocal
+}
+
+// PasswordAuthenticator 本地密码认证器
+type PasswordAuthenticator struct{}
+
+// Authenticate 校验本地 bcrypt 密码
+func (authenticator *PasswordAuthenticator) Authenticate(user *model.User, username, password string) (*model.User, error) {
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Password == nil || !utils.CheckPassword(*user.Password, password) {
+		return nil, ErrWrongPassword
+	}
+
+	return user, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/captcha"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const captchaKey = "captcha:%s" // 验证码答案
+
+// CaptchaError 验证码缺失或错误
+type CaptchaError struct {
+	Message string
+}
+
+func (e *CaptchaError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrCaptchaRequired = &CaptchaError{Message: "请输入验证码"}
+	ErrCaptchaInvalid  = &CaptchaError{Message: "验证码错误或已过期"}
+)
+
+// CaptchaService 图片验证码服务，答案存储于 Redis，校验一次后失效
+type CaptchaService struct{}
+
+// Generate 生成验证码
+func (service *CaptchaService) Generate() (*model.Captcha, error) {
+	captchaConfig := &config.GlobalConfig.Captcha
+	c, err := captcha.New(captchaConfig.Type, captchaConfig.Length, captchaConfig.Width, captchaConfig.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	captchaID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	expire := captchaExpire()
+	if err := db.DB.Redis.Set(fmt.Sprintf(captchaKey, captchaID), c.Answer, expire).Err(); err != nil {
+		return nil, fmt.Errorf("保存验证码失败: %w", err)
+	}
+
+	return &model.Captcha{
+		CaptchaID: captchaID,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(c.Image),
+		ExpiresIn: int64(expire.Seconds()),
+	}, nil
+}
+
+// Verify 校验验证码，无论是否正确验证码都会失效，防止重复尝试
+func (service *CaptchaService) Verify(captchaID, answer string) error {
+	if captchaID == "" || answer == "" {
+		return ErrCaptchaRequired
+	}
+
+	key := fmt.Sprintf(captchaKey, captchaID)
+	pipe := db.DB.Redis.TxPipeline()
+	getCmd := pipe.Get(key)
+	pipe.Del(key)
+	if _, err := pipe.Exec(); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	expected, err := getCmd.Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrCaptchaInvalid
+		}
+		return err
+	}
+	if !strings.EqualFold(expected, strings.TrimSpace(answer)) {
+		return ErrCaptchaInvalid
+	}
+
+	return nil
+}
+
+// captchaExpire 验证码有效期，未配置时为 5 分钟
+func captchaExpire() time.Duration {
+	if expire := config.GlobalConfig.Captcha.Expire; expire > 0 {
+		return time.Duration(expire) * time.Second
+	}
+	return 5 * time.Minute
+}
@@ -0,0 +1,4 @@
+package service
+
+// FindLoginUser 导出给外部测试包使用
+var FindLoginUser = findLoginUser
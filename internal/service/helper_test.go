@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/pkg/auth"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useDB 替换 db.DB 中的连接，测试结束后恢复
+func useDB(tb testing.TB, set func(schema *db.DbSchema)) {
+	tb.Helper()
+
+	old := db.DB
+	schema := &db.DbSchema{}
+	if old != nil {
+		*schema = *old
+	}
+	set(schema)
+	db.DB = schema
+	tb.Cleanup(func() { db.DB = old })
+}
+
+// newMockMySQL 使用 sqlmock 替换 db.DB.MySQL，返回 mock 和执行的 SQL 语句数量
+func newMockMySQL(tb testing.TB) (sqlmock.Sqlmock, *int) {
+	tb.Helper()
+
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	// 统计查询语句数量：Find / Count 走 Query 回调，Scan 走 Row 回调
+	count := new(int)
+	counter := func(*gorm.DB) { *count++ }
+	if err := gormDB.Callback().Query().After("gorm:query").Register("test:count_query", counter); err != nil {
+		tb.Fatal(err)
+	}
+	if err := gormDB.Callback().Row().After("gorm:row").Register("test:count_row", counter); err != nil {
+		tb.Fatal(err)
+	}
+
+	useDB(tb, func(schema *db.DbSchema) { schema.MySQL = gormDB })
+
+	return mock, count
+}
+
+// waitExpectations 等待异步执行的 SQL（如 LoginLimiter.Record 记录登录事件）满足预期
+func waitExpectations(tb testing.TB, mock sqlmock.Sqlmock) {
+	tb.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := mock.ExpectationsWereMet()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			tb.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newMockRedis 使用 miniredis 替换 db.DB.Redis，返回 miniredis 用于快进时间或检查数据
+func newMockRedis(tb testing.TB) *miniredis.Miniredis {
+	tb.Helper()
+
+	server := miniredis.RunT(tb)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	tb.Cleanup(func() { client.Close() })
+	useDB(tb, func(schema *db.DbSchema) { schema.Redis = client })
+
+	return server
+}
+
+// useHS256 使用 HS256 签发 Token，不需要密钥目录
+func useHS256(tb testing.TB) {
+	tb.Helper()
+
+	app := config.GlobalConfig.App
+	tb.Cleanup(func() { config.GlobalConfig.App = app })
+	config.GlobalConfig.App.JWTAlgorithm = auth.AlgorithmHS256
+	config.GlobalConfig.App.JWTSecret = "test-secret"
+}
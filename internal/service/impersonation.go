@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/model"
+	types "ffly-baisc/pkg/type"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const LoginEventImpersonate = "impersonate" // 管理员模拟登录
+
+// ImpersonationService 模拟登录服务，客服人员以其他用户身份登录排查问题
+type ImpersonationService struct {
+	ActorID       uint   // 真实操作人ID
+	ActorUsername string // 真实操作人用户名
+	ClientIP      string
+	UserAgent     string
+}
+
+// Impersonate 以指定用户身份签发限时 Access Token
+// 不能模拟自己，不能模拟禁用用户，操作人不是超级管理员时被模拟用户的权限不能超出操作人的权限
+func (service *ImpersonationService) Impersonate(userID uint, impersonateRequest *model.ImpersonateRequest) (*model.ImpersonationResult, error) {
+	if userID == service.ActorID {
+		return nil, errors.New("不能模拟登录自己")
+	}
+
+	var userService UserService
+	user, err := userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != types.StatusEnabled {
+		return nil, errors.New("只能模拟登录启用状态的用户")
+	}
+
+	if err := service.checkPermissions(userID); err != nil {
+		return nil, err
+	}
+
+	expire := impersonationExpire()
+	if impersonateRequest.ExpiresIn > 0 && time.Duration(impersonateRequest.ExpiresIn)*time.Second < expire {
+		expire = time.Duration(impersonateRequest.ExpiresIn) * time.Second
+	}
+
+	var tokenService TokenService
+	accessToken, sessionID, err := tokenService.IssueImpersonationToken(user.ID, *user.Username,
+		service.ActorID, service.ActorUsername, service.ClientIP, service.UserAgent, expire)
+	if err != nil {
+		return nil, err
+	}
+
+	service.record(user, fmt.Sprintf("%s 模拟登录，有效期 %d 秒，原因: %s", service.ActorUsername, int64(expire.Seconds()), impersonateRequest.Reason))
+
+	return &model.ImpersonationResult{
+		AccessToken: accessToken,
+		ExpiresIn:   int64(expire.Seconds()),
+		ExpiresAt:   time.Now().Add(expire),
+		SessionID:   sessionID,
+		UserID:      user.ID,
+		Username:    *user.Username,
+	}, nil
+}
+
+// checkPermissions 防止通过模拟登录提升权限：被模拟用户的权限码必须是操作人权限码的子集
+func (service *ImpersonationService) checkPermissions(userID uint) error {
+	var authPermissionService AuthPermissionService
+	isSuperAdmin, err := authPermissionService.IsSuperAdmin(service.ActorID)
+	if err != nil {
+		return err
+	}
+	if isSuperAdmin {
+		return nil
+	}
+	userIsSuperAdmin, err := authPermissionService.IsSuperAdmin(userID)
+	if err != nil {
+		return err
+	}
+	if userIsSuperAdmin {
+		return errors.New("只有超级管理员可以模拟登录超级管理员")
+	}
+
+	actorCodes, err := authPermissionService.GetUserPermissionCodes(service.ActorID)
+	if err != nil {
+		return err
+	}
+	userCodes, err := authPermissionService.GetUserPermissionCodes(userID)
+	if err != nil {
+		return err
+	}
+	for _, code := range userCodes {
+		if !slices.Contains(actorCodes, code) {
+			return errors.New("不能模拟登录权限高于自己的用户")
+		}
+	}
+
+	return nil
+}
+
+// record 异步记录模拟登录事件到 api_logs（type 为 login）
+func (service *ImpersonationService) record(user *model.User, message string) {
+	apiLog := &model.ApiLog{
+		UserID:        user.ID,
+		Username:      *user.Username,
+		ActorID:       service.ActorID,
+		ActorUsername: service.ActorUsername,
+		UserAgent:     service.UserAgent,
+		ClientIP:      service.ClientIP,
+		StatusCode:    http.StatusOK,
+		ResponseBody:  message,
+		Type:          "login",
+		Event:         LoginEventImpersonate,
+	}
+
+	go func(apiLog *model.ApiLog) {
+		var apiLogService ApiLogService
+		if err := apiLogService.CreateApiLog(apiLog); err != nil {
+			log.Printf("Failed to record impersonation event: %v\n", err)
+		}
+	}(apiLog)
+}
+
+// impersonationExpire 模拟登录最长有效期，未配置时为 30 分钟
+func impersonationExpire() time.Duration {
+	if expire := config.GlobalConfig.App.ImpersonationExpire; expire > 0 {
+		return time.Duration(expire) * time.Second
+	}
+	return 30 * time.Minute
+}
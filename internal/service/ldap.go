@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/ldap"
+	types "ffly-baisc/pkg/type"
+	"ffly-baisc/pkg/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LDAPAuthenticator LDAP / Active Directory 认证器
+// 以目录中的用户名对应本系统用户名，按配置自动创建用户并将目录组同步为角色
+type LDAPAuthenticator struct{}
+
+// Authenticate 通过目录服务绑定校验密码
+func (authenticator *LDAPAuthenticator) Authenticate(user *model.User, username, password string) (*model.User, error) {
+	ldapConfig := &config.GlobalConfig.LDAP
+	if ldapConfig.URL == "" {
+		return nil, errors.New("未配置 LDAP 服务")
+	}
+
+	entry, err := newLDAPClient(ldapConfig).Authenticate(username, password)
+	if err != nil {
+		if errors.Is(err, ldap.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return nil, ErrWrongPassword
+		}
+		return nil, err
+	}
+
+	// 本地用户不存在，按配置自动创建
+	if user == nil {
+		if !ldapConfig.AutoCreate {
+			return nil, ErrUserNotFound
+		}
+
+		roleIDs, err := authenticator.mapRoles(ldapConfig, entry.Groups)
+		if err != nil {
+			return nil, err
+		}
+		return authenticator.createUser(entry, roleIDs)
+	}
+
+	if ldapConfig.SyncRoles {
+		roleIDs, err := authenticator.mapRoles(ldapConfig, entry.Groups)
+		if err != nil {
+			return nil, err
+		}
+
+		var userRoleService UserRoleService
+		if err := db.DB.MySQL.Transaction(func(tx *gorm.DB) error {
+			return userRoleService.SaveUserRoles(tx, user.ID, roleIDs)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// createUser 创建目录用户，密码为随机值（只能通过目录服务登录）
+func (authenticator *LDAPAuthenticator) createUser(entry *ldap.Entry, roleIDs []uint) (*model.User, error) {
+	randomPassword, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.EncodePassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:   &entry.Username,
+		Password:   &hashedPassword,
+		Status:     types.StatusEnabled,
+		AuthSource: AuthSourceLDAP,
+	}
+	if entry.Nickname != "" {
+		user.Nickname = &entry.Nickname
+	}
+
+	email := strings.ToLower(strings.TrimSpace(entry.Email))
+	if email != "" {
+		// 邮箱已被其他用户使用时不写入，避免唯一索引冲突
+		var count int64
+		if err := db.DB.MySQL.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询邮箱失败: %w", err)
+		}
+		if count == 0 {
+			user.Email = &email
+		}
+	}
+
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	if err := tx.Create(user).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+
+	var userRoleService UserRoleService
+	if err := userRoleService.SaveUserRoles(tx, user.ID, roleIDs); err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, err
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	return user, nil
+}
+
+// mapRoles 按配置将目录组映射为本系统角色ID，并加上默认角色
+func (authenticator *LDAPAuthenticator) mapRoles(ldapConfig *config.LDAPConfig, groups []string) ([]uint, error) {
+	codes := append([]string{}, ldapConfig.DefaultRoles...)
+	for _, mapping := range ldapConfig.GroupMapping {
+		for _, group := range groups {
+			if ldap.GroupMatches(group, mapping.Group) {
+				codes = append(codes, mapping.Role)
+				break
+			}
+		}
+	}
+
+	if len(codes) == 0 {
+		return []uint{}, nil
+	}
+
+	var roleIDs []uint
+	if err := db.DB.MySQL.Model(&model.Role{}).Where("code IN ? AND status = ?", codes, types.StatusEnabled).
+		Pluck("id", &roleIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+
+	return roleIDs, nil
+}
+
+// newLDAPClient 根据配置创建目录客户端
+func newLDAPClient(ldapConfig *config.LDAPConfig) *ldap.Client {
+	return ldap.NewClient(ldap.Config{
+		URL:                ldapConfig.URL,
+		StartTLS:           ldapConfig.StartTLS,
+		InsecureSkipVerify: ldapConfig.InsecureSkipVerify,
+		BindDN:             ldapConfig.BindDN,
+		BindPassword:       ldapConfig.BindPassword,
+		BaseDN:             ldapConfig.BaseDN,
+		UserFilter:         ldapConfig.UserFilter,
+		UsernameAttribute:  ldapConfig.UsernameAttribute,
+		EmailAttribute:     ldapConfig.EmailAttribute,
+		NicknameAttribute:  ldapConfig.NicknameAttribute,
+		GroupAttribute:     ldapConfig.GroupAttribute,
+		GroupBaseDN:        ldapConfig.GroupBaseDN,
+		GroupFilter:        ldapConfig.GroupFilter,
+		Timeout:            time.Duration(ldapConfig.Timeout) * time.Second,
+	})
+}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/ldap/ldaptest"
+	"ffly-baisc/pkg/utils"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// useLDAP 启动包含 alice（属于 admins 组）的目录，系统默认认证方式改为 LDAP
+func useLDAP(t *testing.T) {
+	t.Helper()
+
+	server := ldaptest.NewServer(
+		&ldaptest.Entry{DN: "cn=admin,dc=example,dc=com", Password: "service-password"},
+		&ldaptest.Entry{DN: "uid=alice,ou=people,dc=example,dc=com", Password: "ldap-password", Attributes: map[string][]string{
+			"uid":      {"alice"},
+			"memberOf": {"cn=admins,ou=groups,dc=example,dc=com"},
+		}},
+	)
+	t.Cleanup(server.Close)
+
+	ldapConfig, app := config.GlobalConfig.LDAP, config.GlobalConfig.App
+	t.Cleanup(func() { config.GlobalConfig.LDAP, config.GlobalConfig.App = ldapConfig, app })
+	config.GlobalConfig.App.AuthSource = service.AuthSourceLDAP
+	config.GlobalConfig.LDAP = config.LDAPConfig{
+		URL:          server.URL(),
+		BindDN:       "cn=admin,dc=example,dc=com",
+		BindPassword: "service-password",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		GroupMapping: []config.LDAPGroupMapping{{Group: "admins", Role: "admin"}, {Group: "developers", Role: "developer"}},
+		DefaultRoles: []string{"user"},
+		Timeout:      2,
+	}
+}
+
+// authenticate 按用户的认证方式校验密码，与登录时相同
+func authenticate(user *model.User, username, password string) (*model.User, error) {
+	authenticator, err := service.GetAuthenticator(service.AuthSourceOf(user))
+	if err != nil {
+		return nil, err
+	}
+	return authenticator.Authenticate(user, username, password)
+}
+
+// TestLDAPAuthenticatorSyncsMappedRoles 按目录组映射角色，加上默认角色
+func TestLDAPAuthenticatorSyncsMappedRoles(t *testing.T) {
+	useLDAP(t)
+	config.GlobalConfig.LDAP.SyncRoles = true
+	mock, _ := newMockMySQL(t)
+
+	// alice 只属于 admins 组，映射为 admin 角色；角色均不存在时清空用户角色
+	mock.ExpectQuery("SELECT `id` FROM `roles` WHERE \\(code IN \\(\\?,\\?\\) AND status = \\?\\)").
+		WithArgs("user", "admin", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `user_roles` WHERE user_id = \\?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	username := "alice"
+	user, err := authenticate(&model.User{Username: &username, BaseModel: model.BaseModel{ID: 7}}, "alice", "ldap-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 7 {
+		t.Errorf("user.ID = %d，期望 7", user.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestLDAPFallbackToLocal 默认使用 LDAP 时，指定为本地认证的用户仍使用本地密码，LDAP 用户不能使用本地密码
+func TestLDAPFallbackToLocal(t *testing.T) {
+	useLDAP(t)
+
+	localPassword, err := utils.EncodePassword("local-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	username := "alice"
+
+	tests := []struct {
+		name     string
+		user     *model.User
+		password string
+		wantErr  error
+	}{
+		{"本地管理员使用本地密码", &model.User{Username: &username, Password: &localPassword, AuthSource: service.AuthSourceLocal}, "local-password", nil},
+		{"本地管理员不能使用目录密码", &model.User{Username: &username, Password: &localPassword, AuthSource: service.AuthSourceLocal}, "ldap-password", service.ErrWrongPassword},
+		{"目录用户不能使用本地密码", &model.User{Username: &username, Password: &localPassword}, "local-password", service.ErrWrongPassword},
+		{"本地不存在且未开启自动创建", nil, "ldap-password", service.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authenticate(tt.user, "alice", tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,107 +2,428 @@ package service
 
 import (
 	"errors"
+	"ffly-baisc/internal/config"
 	"ffly-baisc/internal/db"
 	"ffly-baisc/internal/model"
 	"ffly-baisc/pkg/auth"
+	types "ffly-baisc/pkg/type"
 	"ffly-baisc/pkg/utils"
 	"fmt"
-	"time"
+	"net/http"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 type LoginService struct {
-	Username string `json:"username" binding:"required,min=2,max=20"`
-	Password string `json:"password" binding:"required,min=6,max=255"`
+	Username    string `json:"username" binding:"required,min=2,max=100"` // 用户名、邮箱或手机号
+	Password    string `json:"password" binding:"required,max=255"`
+	CaptchaID   string `json:"captchaId"`   // 验证码ID，失败次数达到阈值后必填
+	CaptchaCode string `json:"captchaCode"` // 验证码答案
+	ClientIP    string `json:"-"`           // 客户端IP，由 handler 填充
+	UserAgent   string `json:"-"`           // 用户代理，由 handler 填充
+
+	account string // 登录限制使用的账号标识，匹配到用户时为用户名，否则为输入的登录标识
 }
 
-// LoginLimiter 用户登录限流（一分钟内最多登录5次）
-func LoginLimiter(loginService *LoginService) (bool, error) {
-	// 构造 redis key
-	key := fmt.Sprintf("login_attempts:%s", loginService.Username)
+// LoginResult 登录结果
+// 开启两步验证的用户只返回挑战 Token，需要调用 /login/2fa 换取 Token 对
+type LoginResult struct {
+	*auth.TokenPair
+	TwoFactorRequired      bool     `json:"twoFactorRequired,omitempty"`      // 需要输入两步验证码
+	TwoFactorSetupRequired bool     `json:"twoFactorSetupRequired,omitempty"` // 角色要求两步验证，但用户尚未绑定
+	ChallengeToken         string   `json:"challengeToken,omitempty"`         // 两步验证挑战 Token
+	RecoveryCodes          []string `json:"recoveryCodes,omitempty"`          // 登录时完成两步验证绑定，返回恢复码
+	MustChangePassword     bool     `json:"mustChangePassword,omitempty"`     // 需要修改密码（管理员要求或密码已过期）
+}
 
-	// 使用 Redis 的 INCR 命令增加计数
-	count, err := db.DB.Redis.Incr(key).Result()
+func (service *LoginService) Login() (*LoginResult, error) {
+	// 按用户名、邮箱或手机号查询本地用户，不存在时由认证器决定是否允许登录（目录用户首次登录自动创建）
+	localUser, err := findLoginUser(service.Username)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// 如果是第一次登录，则设置过期时间为 1 分钟
-	if count == 1 {
-		db.DB.Redis.Expire(key, time.Minute)
+	// 登录防暴力破解，同一用户无论使用哪种登录标识都共用失败次数
+	service.account = loginAccount(localUser, service.Username)
+	limiter := &LoginLimiter{Username: service.account, ClientIP: service.ClientIP, UserAgent: service.UserAgent}
+	if err := limiter.Check(); err != nil {
+		return nil, err
+	}
+	// 失败次数达到阈值后需要验证码
+	if err := limiter.CheckCaptcha(service.CaptchaID, service.CaptchaCode); err != nil {
+		return nil, err
 	}
 
-	// 检查是否超过限制
-	if count > 5 {
-		return true, fmt.Errorf("登录次数过多，请稍后再试")
+	// 按用户的认证方式（本地密码 / LDAP）校验密码
+	authenticator, err := GetAuthenticator(AuthSourceOf(localUser))
+	if err != nil {
+		return nil, err
+	}
+	user, err := authenticator.Authenticate(localUser, service.account, service.Password)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrWrongPassword) {
+			var userID uint
+			if localUser != nil {
+				userID = localUser.ID
+			}
+			if err := limiter.Fail(userID, err.Error()); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
 	}
 
-	return false, nil
+	return completeLogin(user, limiter)
+}
+
+// CaptchaRequired 下一次登录是否需要验证码，用于登录失败时提示前端
+func (service *LoginService) CaptchaRequired() bool {
+	account := service.account
+	if account == "" {
+		account = service.Username
+	}
+	limiter := &LoginLimiter{Username: account, ClientIP: service.ClientIP}
+	required, _ := limiter.CaptchaRequired()
+	return required
 }
 
-func (service *LoginService) Login() (*auth.TokenPair, error) {
-	// 用户登录限流
-	isLimit, err := LoginLimiter(service)
+// SMSLoginCodeService 发送短信登录验证码
+type SMSLoginCodeService struct {
+	Phone       string `json:"phone" binding:"required"`
+	CaptchaID   string `json:"captchaId"`   // 验证码ID，失败次数达到阈值后必填
+	CaptchaCode string `json:"captchaCode"` // 验证码答案
+	ClientIP    string `json:"-"`           // 客户端IP，由 handler 填充
+	UserAgent   string `json:"-"`           // 用户代理，由 handler 填充
+}
+
+// Send 向手机号发送登录验证码，手机号未注册时静默返回，避免泄露用户信息
+// 发送间隔和每日次数沿用邮箱/手机号验证的限制
+func (service *SMSLoginCodeService) Send() error {
+	if !config.GlobalConfig.SMS.Login {
+		return errors.New("未开启短信验证码登录")
+	}
+	phone := strings.TrimSpace(service.Phone)
+	if !utils.IsPhone(phone) {
+		return errors.New("手机号不合规")
+	}
+
+	user, err := findUserByPhone(phone)
+	if err != nil {
+		return err
+	}
+
+	// 账号被锁定时不发送，失败次数达到阈值后需要图片验证码
+	limiter := &LoginLimiter{Username: loginAccount(user, phone), ClientIP: service.ClientIP, UserAgent: service.UserAgent}
+	if err := limiter.Check(); err != nil {
+		return err
+	}
+	if err := limiter.CheckCaptcha(service.CaptchaID, service.CaptchaCode); err != nil {
+		return err
+	}
+
+	// 目录用户只能通过目录服务认证
+	if user == nil || user.Status == types.StatusDisabled || AuthSourceOf(user) != AuthSourceLocal {
+		return nil
+	}
+
+	var verificationService VerificationService
+	return verificationService.SendCode(user.ID, VerifyTypeLogin, phone)
+}
+
+// SMSLoginService 短信验证码登录
+type SMSLoginService struct {
+	Phone     string `json:"phone" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+	ClientIP  string `json:"-"` // 客户端IP，由 handler 填充
+	UserAgent string `json:"-"` // 用户代理，由 handler 填充
+}
+
+// Login 校验短信验证码并登录，验证码错误计入登录失败次数
+func (service *SMSLoginService) Login() (*LoginResult, error) {
+	if !config.GlobalConfig.SMS.Login {
+		return nil, errors.New("未开启短信验证码登录")
+	}
+	phone := strings.TrimSpace(service.Phone)
+
+	user, err := findUserByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := &LoginLimiter{Username: loginAccount(user, phone), ClientIP: service.ClientIP, UserAgent: service.UserAgent}
+	if err := limiter.Check(); err != nil {
+		return nil, err
+	}
+
+	var verificationService VerificationService
+	userID, err := verificationService.CheckCode(VerifyTypeLogin, phone, service.Code)
+	// 验证码发送后手机号可能已变更
+	if err == nil && (user == nil || user.ID != userID || AuthSourceOf(user) != AuthSourceLocal) {
+		err = errors.New("验证码无效或已过期")
+	}
+	if err != nil {
+		var failedUserID uint
+		if user != nil {
+			failedUserID = user.ID
+		}
+		if err := limiter.Fail(failedUserID, "短信验证码错误"); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	return completeLogin(user, limiter)
+}
+
+// completeLogin 凭证校验通过后检查用户状态，需要两步验证时返回挑战 Token，否则签发 Token 对
+func completeLogin(user *model.User, limiter *LoginLimiter) (*LoginResult, error) {
+	// 验证用户状态
+	if user.Status == types.StatusDisabled {
+		limiter.Record(user.ID, LoginEventFailure, http.StatusUnauthorized, "用户已被禁用")
+		return nil, errors.New("用户已被禁用")
+	}
+	if user.Status == types.StatusPending && config.GlobalConfig.Verify.Required {
+		limiter.Record(user.ID, LoginEventFailure, http.StatusUnauthorized, "账号尚未完成邮箱/手机号验证")
+		return nil, errors.New("账号尚未完成邮箱/手机号验证")
+	}
+
+	// 两步验证
+	var twoFactorService TwoFactorService
+	required, err := twoFactorService.IsRequired(user.ID)
 	if err != nil {
 		return nil, err
 	}
-	if isLimit {
+	if user.TwoFactorEnabled || required {
+		challengeToken, err := twoFactorService.IssueChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{
+			TwoFactorRequired:      user.TwoFactorEnabled,
+			TwoFactorSetupRequired: !user.TwoFactorEnabled,
+			ChallengeToken:         challengeToken,
+		}, nil
+	}
+
+	// 生成 Token 对（Access Token + Refresh Token）
+	var tokenService TokenService
+	tokenPair, err := tokenService.IssueTokenPair(user.ID, *user.Username, limiter.ClientIP, limiter.UserAgent)
+	if err != nil {
 		return nil, err
 	}
+	limiter.Succeed(user.ID)
+
+	var passwordPolicyService PasswordPolicyService
+	return &LoginResult{
+		TokenPair:          tokenPair,
+		MustChangePassword: passwordPolicyService.MustChangePassword(user),
+	}, nil
+}
+
+// findLoginUser 按用户名、邮箱或手机号查询用户，不存在时返回 nil
+// 邮箱和手机号格式的标识优先匹配邮箱和手机号，避免与之相同的用户名占用其他用户的登录标识
+func findLoginUser(identifier string) (*model.User, error) {
+	identifier = strings.TrimSpace(identifier)
+
+	var conditions [][2]string
+	if strings.Contains(identifier, "@") {
+		conditions = append(conditions, [2]string{"email", strings.ToLower(identifier)})
+	}
+	if utils.IsPhone(identifier) {
+		conditions = append(conditions, [2]string{"phone", identifier})
+	}
+	conditions = append(conditions, [2]string{"username", identifier})
+
+	for _, condition := range conditions {
+		var user model.User
+		err := db.DB.MySQL.Where(condition[0]+" = ?", condition[1]).First(&user).Error
+		if err == nil {
+			return &user, nil
+		}
+		// gorm.ErrRecordNotFound 是 gorm 的一个错误类型，表示没有找到记录
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
 
-	// 检查用户名是否存在
+	return nil, nil
+}
+
+// findUserByPhone 按手机号查询用户，不存在时返回 nil
+func findUserByPhone(phone string) (*model.User, error) {
 	var user model.User
-	if err := db.DB.MySQL.Where("username = ?", service.Username).First(&user).Error; err != nil {
+	if err := db.DB.MySQL.Where("phone = ?", phone).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// gorm.ErrRecordNotFound 是 gorm 的一个错误类型，表示没有找到记录
-			// Is 用于判断错误是否为 gorm.ErrRecordNotFound
-			return nil, errors.New("用户名不存在")
+			return nil, nil
 		}
 		return nil, err
 	}
 
-	// 验证密码
-	if !utils.CheckPassword(*user.Password, service.Password) {
-		return nil, errors.New("密码错误")
+	return &user, nil
+}
+
+// loginAccount 登录限制使用的账号标识，匹配到用户时统一为用户名
+func loginAccount(user *model.User, identifier string) string {
+	if user != nil && user.Username != nil {
+		return *user.Username
+	}
+	return strings.TrimSpace(identifier)
+}
+
+// TwoFactorLoginService 两步验证登录（第二步）
+type TwoFactorLoginService struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code"` // TOTP 验证码或恢复码，获取绑定信息时可为空
+	ClientIP       string `json:"-"`    // 客户端IP，由 handler 填充
+	UserAgent      string `json:"-"`    // 用户代理，由 handler 填充
+}
+
+// Setup 角色要求两步验证但用户尚未绑定时，凭挑战 Token 获取绑定信息
+func (service *TwoFactorLoginService) Setup() (*model.TwoFactorSetup, error) {
+	var twoFactorService TwoFactorService
+	claims, err := twoFactorService.VerifyChallenge(service.ChallengeToken)
+	if err != nil {
+		return nil, err
 	}
 
+	return twoFactorService.Setup(claims.UserID)
+}
+
+// Login 校验挑战 Token 和验证码，签发 Token 对
+func (service *TwoFactorLoginService) Login() (*LoginResult, error) {
+	if service.Code == "" {
+		return nil, errors.New("验证码不能为空")
+	}
+
+	var twoFactorService TwoFactorService
+	claims, err := twoFactorService.VerifyChallenge(service.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 登录防暴力破解
+	limiter := &LoginLimiter{Username: claims.Username, ClientIP: service.ClientIP, UserAgent: service.UserAgent}
+	if err := limiter.Check(); err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := db.DB.MySQL.First(&user, claims.UserID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	// 验证用户状态
+	if user.Status == types.StatusDisabled {
+		limiter.Record(user.ID, LoginEventFailure, http.StatusUnauthorized, "用户已被禁用")
+		return nil, errors.New("用户已被禁用")
+	}
+
+	var recoveryCodes []string
+	if user.TwoFactorEnabled {
+		ok, err := twoFactorService.VerifyCode(&user, service.Code)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if err := limiter.Fail(user.ID, "两步验证码错误"); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("验证码错误")
+		}
+	} else {
+		// 角色要求两步验证的用户首次登录，校验验证码并完成绑定
+		recoveryCodes, err = twoFactorService.Enable(user.ID, service.Code)
+		if err != nil {
+			if errors.Is(err, ErrTwoFactorCodeInvalid) {
+				if err := limiter.Fail(user.ID, "两步验证码错误"); err != nil {
+					return nil, err
+				}
+			}
+			return nil, err
+		}
+	}
+
+	twoFactorService.ConsumeChallenge(claims)
+
 	// 生成 Token 对（Access Token + Refresh Token）
-	tokenPair, err := auth.GenerateTokenPair(user.ID, *user.Username)
+	var tokenService TokenService
+	tokenPair, err := tokenService.IssueTokenPair(user.ID, *user.Username, service.ClientIP, service.UserAgent)
 	if err != nil {
 		return nil, err
 	}
+	limiter.Succeed(user.ID)
 
-	return tokenPair, nil
+	var passwordPolicyService PasswordPolicyService
+	return &LoginResult{
+		TokenPair:          tokenPair,
+		RecoveryCodes:      recoveryCodes,
+		MustChangePassword: passwordPolicyService.MustChangePassword(&user),
+	}, nil
 }
 
 type RegisterService struct {
 	Username        *string `json:"username" binding:"required,min=2,max=20"`
-	Password        *string `json:"password" binding:"required,min=6,max=255"`
-	ConfirmPassword *string `json:"confirmPassword" binding:"required,min=6,max=255"`
+	Password        *string `json:"password" binding:"required,max=255"` // 长度等规则由密码策略校验
+	ConfirmPassword *string `json:"confirmPassword" binding:"required,max=255"`
 	Nickname        *string `json:"nickname"`
 	Email           *string `json:"email" binding:"omitempty,email"` // omitempty 允许为空
 	Phone           *string `json:"phone" binding:"omitempty,e164"`  // omitempty 允许为空
+	CaptchaID       string  `json:"captchaId"`                       // 验证码ID，开启注册验证码时必填
+	CaptchaCode     string  `json:"captchaCode"`                     // 验证码答案
 }
 
 func (service *RegisterService) Register() error {
+	// 注册验证码
+	if config.GlobalConfig.Captcha.Enabled && config.GlobalConfig.Captcha.Register {
+		var captchaService CaptchaService
+		if err := captchaService.Verify(service.CaptchaID, service.CaptchaCode); err != nil {
+			return err
+		}
+	}
+
 	// 密码存在并且检查密码是否一致
 	if *service.Password != "" && *service.Password != *service.ConfirmPassword {
 		return errors.New("两次密码输入不一致")
 	}
 
-	// 创建用户
+	// 需要验证时，邮箱和手机号至少填写一个
+	verifyRequired := config.GlobalConfig.Verify.Required
+	hasEmail := service.Email != nil && *service.Email != ""
+	hasPhone := service.Phone != nil && *service.Phone != ""
+	if verifyRequired && !hasEmail && !hasPhone {
+		return errors.New("邮箱和手机号至少填写一个")
+	}
+
+	// 创建用户，需要验证时为待验证状态
 	userCreateRequest := &model.UserCreateRequest{
 		Username: service.Username,
 		Password: service.Password,
 		Nickname: service.Nickname,
 		Email:    service.Email,
 		Phone:    service.Phone,
+		Status:   types.StatusEnabled,
+	}
+	if verifyRequired {
+		userCreateRequest.Status = types.StatusPending
 	}
 	var userService UserService
 	if err := userService.CreateUser(userCreateRequest); err != nil {
 		return err
 	}
 
+	// 发送验证码
+	if hasEmail || hasPhone {
+		var verificationService VerificationService
+		if err := verificationService.SendUserCodes(&model.User{
+			Email:     service.Email,
+			Phone:     service.Phone,
+			BaseModel: model.BaseModel{ID: userCreateRequest.ID},
+		}); err != nil {
+			return fmt.Errorf("注册成功，但验证码发送失败: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LoginEventSuccess = "login_success" // 登录成功
+	LoginEventFailure = "login_failure" // 登录失败
+	LoginEventLocked  = "login_locked"  // 失败次数过多被锁定
+	LoginEventBlocked = "login_blocked" // 锁定或退避期间的登录请求被拒绝
+	LoginEventUnlock  = "login_unlock"  // 管理员解锁
+
+	loginFailUserKey = "login:fail:user:%s" // 用户名失败次数
+	loginFailIPKey   = "login:fail:ip:%s"   // IP 失败次数
+	loginLockUserKey = "login:lock:user:%s" // 用户名锁定
+	loginLockIPKey   = "login:lock:ip:%s"   // IP 锁定
+	loginBackoffKey  = "login:backoff:%s"   // 用户名退避等待
+)
+
+// LoginLimitError 登录被限制（锁定或退避等待中）
+type LoginLimitError struct {
+	Locked     bool          // 是否为锁定
+	RetryAfter time.Duration // 多久后可以重试
+}
+
+func (e *LoginLimitError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("登录失败次数过多，账号已被锁定，请 %d 分钟后再试", int(math.Ceil(e.RetryAfter.Minutes())))
+	}
+	return fmt.Sprintf("登录失败次数过多，请 %d 秒后再试", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// LoginLimiter 登录防暴力破解
+// 按用户名和客户端 IP 分别统计失败次数：每次失败后按指数退避等待，超过阈值后临时锁定，登录成功后清除该用户名的失败记录
+type LoginLimiter struct {
+	Username  string
+	ClientIP  string
+	UserAgent string
+}
+
+// Check 检查是否处于锁定或退避等待中
+func (limiter *LoginLimiter) Check() error {
+	username := normalizeLoginUsername(limiter.Username)
+
+	for _, key := range []string{
+		fmt.Sprintf(loginLockUserKey, username),
+		fmt.Sprintf(loginLockIPKey, limiter.ClientIP),
+	} {
+		ttl, err := db.DB.Redis.TTL(key).Result()
+		if err != nil {
+			return err
+		}
+		if ttl > 0 {
+			limitErr := &LoginLimitError{Locked: true, RetryAfter: ttl}
+			limiter.Record(0, LoginEventBlocked, http.StatusTooManyRequests, limitErr.Error())
+			return limitErr
+		}
+	}
+
+	ttl, err := db.DB.Redis.PTTL(fmt.Sprintf(loginBackoffKey, username)).Result()
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		limitErr := &LoginLimitError{RetryAfter: ttl}
+		limiter.Record(0, LoginEventBlocked, http.StatusTooManyRequests, limitErr.Error())
+		return limitErr
+	}
+
+	return nil
+}
+
+// Fail 记录一次登录失败，计算退避时长，超过阈值时锁定用户名或 IP
+func (limiter *LoginLimiter) Fail(userID uint, reason string) error {
+	limitConfig := loginLimitConfig()
+	username := normalizeLoginUsername(limiter.Username)
+
+	userKey := fmt.Sprintf(loginFailUserKey, username)
+	ipKey := fmt.Sprintf(loginFailIPKey, limiter.ClientIP)
+
+	pipe := db.DB.Redis.TxPipeline()
+	userCount := pipe.Incr(userKey)
+	pipe.Expire(userKey, limitConfig.FailureWindow)
+	ipCount := pipe.Incr(ipKey)
+	pipe.Expire(ipKey, limitConfig.FailureWindow)
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("记录登录失败次数失败: %w", err)
+	}
+
+	limiter.Record(userID, LoginEventFailure, http.StatusUnauthorized, reason)
+
+	if userCount.Val() >= int64(limitConfig.MaxFailures) {
+		db.DB.Redis.Set(fmt.Sprintf(loginLockUserKey, username), userCount.Val(), limitConfig.LockDuration)
+		db.DB.Redis.Del(userKey, fmt.Sprintf(loginBackoffKey, username))
+		limiter.Record(userID, LoginEventLocked, http.StatusTooManyRequests,
+			fmt.Sprintf("用户名连续失败 %d 次，锁定 %s", userCount.Val(), limitConfig.LockDuration))
+	} else {
+		// 指数退避：base * 2^(n-1)，不超过 max
+		backoff := limitConfig.BackoffBase << (userCount.Val() - 1)
+		if backoff <= 0 || backoff > limitConfig.BackoffMax {
+			backoff = limitConfig.BackoffMax
+		}
+		db.DB.Redis.Set(fmt.Sprintf(loginBackoffKey, username), 1, backoff)
+	}
+
+	if ipCount.Val() >= int64(limitConfig.IPMaxFailures) {
+		db.DB.Redis.Set(fmt.Sprintf(loginLockIPKey, limiter.ClientIP), ipCount.Val(), limitConfig.LockDuration)
+		db.DB.Redis.Del(ipKey)
+		limiter.Record(userID, LoginEventLocked, http.StatusTooManyRequests,
+			fmt.Sprintf("IP %s 失败 %d 次，锁定 %s", limiter.ClientIP, ipCount.Val(), limitConfig.LockDuration))
+	}
+
+	return nil
+}
+
+// Succeed 登录成功，清除该用户名的失败记录（IP 失败次数不清除，避免攻击者用自己的账号重置计数）
+func (limiter *LoginLimiter) Succeed(userID uint) {
+	username := normalizeLoginUsername(limiter.Username)
+	db.DB.Redis.Del(fmt.Sprintf(loginFailUserKey, username), fmt.Sprintf(loginBackoffKey, username))
+
+	limiter.Record(userID, LoginEventSuccess, http.StatusOK, "登录成功")
+}
+
+// CaptchaRequired 是否需要验证码：启用验证码且用户名或 IP 的失败次数达到阈值
+func (limiter *LoginLimiter) CaptchaRequired() (bool, error) {
+	captchaConfig := &config.GlobalConfig.Captcha
+	if !captchaConfig.Enabled {
+		return false, nil
+	}
+	if captchaConfig.LoginThreshold <= 0 {
+		return true, nil
+	}
+
+	counts, err := db.DB.Redis.MGet(
+		fmt.Sprintf(loginFailUserKey, normalizeLoginUsername(limiter.Username)),
+		fmt.Sprintf(loginFailIPKey, limiter.ClientIP),
+	).Result()
+	if err != nil {
+		return false, fmt.Errorf("查询登录失败次数失败: %w", err)
+	}
+	for _, count := range counts {
+		value, _ := count.(string)
+		if n, _ := strconv.Atoi(value); n >= captchaConfig.LoginThreshold {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CheckCaptcha 需要验证码时校验验证码，验证码错误不计入失败次数（避免误输验证码导致锁定）
+func (limiter *LoginLimiter) CheckCaptcha(captchaID, captchaCode string) error {
+	required, err := limiter.CaptchaRequired()
+	if err != nil {
+		return err
+	}
+	if !required {
+		return nil
+	}
+
+	var captchaService CaptchaService
+	if err := captchaService.Verify(captchaID, captchaCode); err != nil {
+		limiter.Record(0, LoginEventFailure, http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Unlock 解除用户名的锁定并清除失败记录
+func (limiter *LoginLimiter) Unlock(userID uint, operator string) error {
+	username := normalizeLoginUsername(limiter.Username)
+	if err := db.DB.Redis.Del(
+		fmt.Sprintf(loginLockUserKey, username),
+		fmt.Sprintf(loginFailUserKey, username),
+		fmt.Sprintf(loginBackoffKey, username),
+	).Err(); err != nil {
+		return fmt.Errorf("解锁失败: %w", err)
+	}
+
+	limiter.Record(userID, LoginEventUnlock, http.StatusOK, fmt.Sprintf("管理员 %s 解锁", operator))
+	return nil
+}
+
+// Record 异步记录登录事件到 api_logs（type 为 login）
+func (limiter *LoginLimiter) Record(userID uint, event string, statusCode int, message string) {
+	apiLog := &model.ApiLog{
+		UserID:       userID,
+		Username:     limiter.Username,
+		UserAgent:    limiter.UserAgent,
+		ClientIP:     limiter.ClientIP,
+		StatusCode:   statusCode,
+		ResponseBody: message,
+		Type:         "login",
+		Event:        event,
+	}
+
+	go func(apiLog *model.ApiLog) {
+		var apiLogService ApiLogService
+		if err := apiLogService.CreateApiLog(apiLog); err != nil {
+			log.Printf("Failed to record login event: %v\n", err)
+		}
+	}(apiLog)
+}
+
+// loginLimit 登录限制配置（已转换为时长并填充默认值）
+type loginLimit struct {
+	MaxFailures   int
+	IPMaxFailures int
+	FailureWindow time.Duration
+	LockDuration  time.Duration
+	BackoffBase   time.Duration
+	BackoffMax    time.Duration
+}
+
+// loginLimitConfig 读取登录限制配置，未配置时使用默认值
+func loginLimitConfig() loginLimit {
+	appConfig := config.GlobalConfig.App
+	limit := loginLimit{
+		MaxFailures:   appConfig.LoginMaxFailures,
+		IPMaxFailures: appConfig.LoginIPMaxFailures,
+		FailureWindow: time.Duration(appConfig.LoginFailureWindow) * time.Second,
+		LockDuration:  time.Duration(appConfig.LoginLockDuration) * time.Second,
+		BackoffBase:   time.Duration(appConfig.LoginBackoffBase) * time.Second,
+		BackoffMax:    time.Duration(appConfig.LoginBackoffMax) * time.Second,
+	}
+
+	if limit.MaxFailures <= 0 {
+		limit.MaxFailures = 5
+	}
+	if limit.IPMaxFailures <= 0 {
+		limit.IPMaxFailures = 20
+	}
+	if limit.FailureWindow <= 0 {
+		limit.FailureWindow = 15 * time.Minute
+	}
+	if limit.LockDuration <= 0 {
+		limit.LockDuration = 15 * time.Minute
+	}
+	if limit.BackoffBase <= 0 {
+		limit.BackoffBase = time.Second
+	}
+	if limit.BackoffMax <= 0 {
+		limit.BackoffMax = time.Minute
+	}
+
+	return limit
+}
+
+// normalizeLoginUsername 规范化用户名，用户名不区分大小写
+func normalizeLoginUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
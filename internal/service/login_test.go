@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/model"
+	"ffly-baisc/internal/service"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestFindLoginUserByIdentifierShape 邮箱和手机号格式的标识优先匹配邮箱和手机号，与之相同的用户名不能占用其他用户的登录标识
+func TestFindLoginUserByIdentifierShape(t *testing.T) {
+	userRows := func(id int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow(id)
+	}
+
+	tests := []struct {
+		name       string
+		identifier string
+		expect     func(mock sqlmock.Sqlmock)
+		wantID     uint
+	}{
+		{
+			name:       "邮箱优先于同名用户名",
+			identifier: " Bob@Example.com ",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WHERE email = \\?").WithArgs("bob@example.com", 1).WillReturnRows(userRows(2))
+			},
+			wantID: 2,
+		},
+		{
+			name:       "手机号优先于同名用户名",
+			identifier: "13800138000",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WHERE phone = \\?").WithArgs("13800138000", 1).WillReturnRows(userRows(3))
+			},
+			wantID: 3,
+		},
+		{
+			name:       "邮箱不存在时匹配用户名",
+			identifier: "old@name",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WHERE email = \\?").WithArgs("old@name", 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("WHERE username = \\?").WithArgs("old@name", 1).WillReturnRows(userRows(4))
+			},
+			wantID: 4,
+		},
+		{
+			name:       "用户名",
+			identifier: "alice",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WHERE username = \\?").WithArgs("alice", 1).WillReturnRows(userRows(5))
+			},
+			wantID: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := newMockMySQL(t)
+			tt.expect(mock)
+
+			user, err := service.FindLoginUser(tt.identifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user == nil || user.ID != tt.wantID {
+				t.Errorf("user = %+v，期望 ID %d", user, tt.wantID)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestCreateUserRejectsLoginIdentifierUsername 用户名不能是邮箱或手机号格式
+func TestCreateUserRejectsLoginIdentifierUsername(t *testing.T) {
+	for _, username := range []string{"bob@example.com", "13800138000"} {
+		t.Run(username, func(t *testing.T) {
+			mock, _ := newMockMySQL(t)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			password := "a-long-password"
+			var userService service.UserService
+			if err := userService.CreateUser(&model.UserCreateRequest{Username: &username, Password: &password}); err == nil {
+				t.Error("邮箱或手机号格式的用户名应被拒绝")
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
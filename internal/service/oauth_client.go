@@ -0,0 +1,237 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/query"
+	types "ffly-baisc/pkg/type"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	OAuthGrantAuthorizationCode = "authorization_code" // 授权码模式
+	OAuthGrantClientCredentials = "client_credentials" // 客户端凭证模式
+)
+
+// OAuthClientService 授权服务器客户端管理服务
+type OAuthClientService struct{}
+
+// GetOAuthClientList 获取客户端列表
+func (service *OAuthClientService) GetOAuthClientList(c *gin.Context) ([]*model.OAuthClient, *query.Pagination, error) {
+	clients, pagination, err := query.GetQueryData[model.OAuthClient](db.DB.MySQL, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *clients, pagination, nil
+}
+
+// GetOAuthClientByID 获取客户端
+func (service *OAuthClientService) GetOAuthClientByID(id uint) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	if err := db.DB.MySQL.First(&client, id).Error; err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %w", err)
+	}
+
+	return &client, nil
+}
+
+// GetOAuthClientByClientID 根据 client_id 获取启用的客户端
+func (service *OAuthClientService) GetOAuthClientByClientID(clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	if err := db.DB.MySQL.Where("client_id = ? AND status = ?", clientID, types.StatusEnabled).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("客户端不存在或已停用")
+		}
+		return nil, fmt.Errorf("获取客户端失败: %w", err)
+	}
+
+	return &client, nil
+}
+
+// CreateOAuthClient 创建客户端，机密客户端返回客户端密钥（只返回这一次）
+func (service *OAuthClientService) CreateOAuthClient(clientCreateRequest *model.OAuthClientCreateRequest) (*model.OAuthClientCreateResult, error) {
+	if err := validateOAuthClient(clientCreateRequest.GrantTypes, clientCreateRequest.RedirectURIs, clientCreateRequest.Public); err != nil {
+		return nil, err
+	}
+
+	clientID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	status := clientCreateRequest.Status
+	if status == 0 {
+		status = types.StatusEnabled
+	}
+
+	client := &model.OAuthClient{
+		ClientID:     clientID,
+		Name:         clientCreateRequest.Name,
+		RedirectURIs: clientCreateRequest.RedirectURIs,
+		GrantTypes:   clientCreateRequest.GrantTypes,
+		Scopes:       clientCreateRequest.Scopes,
+		Public:       clientCreateRequest.Public,
+		SkipConsent:  clientCreateRequest.SkipConsent,
+		Status:       status,
+	}
+
+	var clientSecret string
+	if !client.Public {
+		clientSecret, err = randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		client.ClientSecretHash = hashClientSecret(clientSecret)
+	}
+
+	if err := db.DB.MySQL.Create(client).Error; err != nil {
+		return nil, fmt.Errorf("创建客户端失败: %w", err)
+	}
+
+	return &model.OAuthClientCreateResult{OAuthClient: client, ClientSecret: clientSecret}, nil
+}
+
+// PatchOAuthClient 部分更新客户端
+func (service *OAuthClientService) PatchOAuthClient(id uint, clientPatchRequest *model.OAuthClientPatchRequest) error {
+	client, err := service.GetOAuthClientByID(id)
+	if err != nil {
+		return err
+	}
+
+	// 切片字段需要经过 serializer 序列化，因此使用结构体 + Select 更新
+	var columns []string
+	if clientPatchRequest.Name != nil {
+		client.Name = *clientPatchRequest.Name
+		columns = append(columns, "name")
+	}
+	if clientPatchRequest.RedirectURIs != nil {
+		client.RedirectURIs = clientPatchRequest.RedirectURIs
+		columns = append(columns, "redirect_uris")
+	}
+	if clientPatchRequest.GrantTypes != nil {
+		client.GrantTypes = clientPatchRequest.GrantTypes
+		columns = append(columns, "grant_types")
+	}
+	if clientPatchRequest.Scopes != nil {
+		client.Scopes = clientPatchRequest.Scopes
+		columns = append(columns, "scopes")
+	}
+	if clientPatchRequest.SkipConsent != nil {
+		client.SkipConsent = *clientPatchRequest.SkipConsent
+		columns = append(columns, "skip_consent")
+	}
+	if clientPatchRequest.Status != 0 {
+		client.Status = clientPatchRequest.Status
+		columns = append(columns, "status")
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if err := validateOAuthClient(client.GrantTypes, client.RedirectURIs, client.Public); err != nil {
+		return err
+	}
+
+	if err := db.DB.MySQL.Model(client).Select(columns).Updates(client).Error; err != nil {
+		return fmt.Errorf("更新客户端失败: %w", err)
+	}
+
+	return nil
+}
+
+// ResetOAuthClientSecret 重置机密客户端的密钥
+func (service *OAuthClientService) ResetOAuthClientSecret(id uint) (*model.OAuthClientCreateResult, error) {
+	client, err := service.GetOAuthClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if client.Public {
+		return nil, errors.New("公共客户端没有密钥")
+	}
+
+	clientSecret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.DB.MySQL.Model(&model.OAuthClient{}).Where("id = ?", id).
+		Update("client_secret_hash", hashClientSecret(clientSecret)).Error; err != nil {
+		return nil, fmt.Errorf("重置客户端密钥失败: %w", err)
+	}
+
+	return &model.OAuthClientCreateResult{OAuthClient: client, ClientSecret: clientSecret}, nil
+}
+
+// DeleteOAuthClient 删除客户端，同时删除用户授权记录
+func (service *OAuthClientService) DeleteOAuthClient(id uint) error {
+	client, err := service.GetOAuthClientByID(id)
+	if err != nil {
+		return err
+	}
+
+	return db.DB.MySQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", client.ClientID).Unscoped().Delete(&model.OAuthConsent{}).Error; err != nil {
+			return fmt.Errorf("删除授权记录失败: %w", err)
+		}
+		if err := tx.Delete(&model.OAuthClient{}, id).Error; err != nil {
+			return fmt.Errorf("删除客户端失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Authenticate 校验客户端凭证，公共客户端只校验 client_id
+func (service *OAuthClientService) Authenticate(clientID, clientSecret string) (*model.OAuthClient, error) {
+	client, err := service.GetOAuthClientByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if clientSecret != "" {
+			return nil, errors.New("公共客户端不能使用客户端密钥")
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.ClientSecretHash), []byte(hashClientSecret(clientSecret))) != 1 {
+		return nil, errors.New("客户端密钥错误")
+	}
+
+	return client, nil
+}
+
+// validateOAuthClient 校验客户端配置
+func validateOAuthClient(grantTypes, redirectURIs []string, public bool) error {
+	if slices.Contains(grantTypes, OAuthGrantAuthorizationCode) && len(redirectURIs) == 0 {
+		return errors.New("授权码模式必须配置回调地址")
+	}
+	if public && slices.Contains(grantTypes, OAuthGrantClientCredentials) {
+		return errors.New("公共客户端不能使用客户端凭证模式")
+	}
+	return nil
+}
+
+// randomHex 生成 n 字节的随机十六进制字符串
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashClientSecret 计算客户端密钥哈希，客户端密钥为高熵随机值，使用 SHA-256 即可
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/oidc"
+	types "ffly-baisc/pkg/type"
+	"ffly-baisc/pkg/utils"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateKey    = "oidc:state:%s"  // 授权请求状态（provider、nonce、code_verifier）
+	OIDCStateExpire = 10 * time.Minute // 授权请求有效期
+)
+
+// oidcProviders 身份提供方缓存（元数据和 JWKS 在首次使用时拉取）
+var oidcProviders sync.Map
+
+// OIDCService OpenID Connect 登录服务（授权码 + PKCE）
+type OIDCService struct{}
+
+// GetProviders 获取已配置的身份提供方列表
+func (service *OIDCService) GetProviders() []*model.OIDCProvider {
+	providers := make([]*model.OIDCProvider, 0, len(config.GlobalConfig.OIDC.Providers))
+	for _, providerConfig := range config.GlobalConfig.OIDC.Providers {
+		title := providerConfig.Title
+		if title == "" {
+			title = providerConfig.Name
+		}
+		providers = append(providers, &model.OIDCProvider{
+			Name:     providerConfig.Name,
+			Title:    title,
+			LoginURL: fmt.Sprintf("/api/v1/oidc/%s/login", providerConfig.Name),
+		})
+	}
+
+	return providers
+}
+
+// AuthURL 生成身份提供方授权地址，state、nonce、code_verifier 保存在 Redis 中
+// 返回的 state 需要由 handler 写入浏览器 Cookie，回调时校验，将授权请求绑定到发起登录的浏览器
+func (service *OIDCService) AuthURL(providerName string) (string, string, error) {
+	providerConfig, provider, err := getOIDCProvider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	stateKey := fmt.Sprintf(oidcStateKey, state)
+	pipe := db.DB.Redis.TxPipeline()
+	pipe.HMSet(stateKey, map[string]interface{}{
+		"provider":      providerConfig.Name,
+		"nonce":         nonce,
+		"code_verifier": codeVerifier,
+	})
+	pipe.Expire(stateKey, OIDCStateExpire)
+	if _, err := pipe.Exec(); err != nil {
+		return "", "", fmt.Errorf("保存授权状态失败: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// OIDCCallbackService 身份提供方回调
+type OIDCCallbackService struct {
+	Code             string `form:"code"`
+	State            string `form:"state" binding:"required"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+	BrowserState     string `form:"-"` // 发起登录时写入浏览器 Cookie 的 state，由 handler 填充
+	ClientIP         string `form:"-"` // 客户端IP，由 handler 填充
+	UserAgent        string `form:"-"` // 用户代理，由 handler 填充
+}
+
+// Callback 校验授权状态，换取并校验 ID Token，绑定或创建用户后按与密码登录相同的流程完成登录
+// （锁定、用户状态、待验证和两步验证检查）
+func (service *OIDCCallbackService) Callback(providerName string) (*LoginResult, error) {
+	// state 必须与发起登录的浏览器 Cookie 中的一致，防止登录 CSRF（诱导受害者登录到攻击者的账号）
+	if service.State == "" || subtle.ConstantTimeCompare([]byte(service.State), []byte(service.BrowserState)) != 1 {
+		return nil, errors.New("授权请求无效或已过期，请重新登录")
+	}
+
+	// 取出并删除授权状态，保证只能使用一次
+	stateKey := fmt.Sprintf(oidcStateKey, service.State)
+	pipe := db.DB.Redis.TxPipeline()
+	stateCmd := pipe.HGetAll(stateKey)
+	pipe.Del(stateKey)
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+	state := stateCmd.Val()
+	if len(state) == 0 || state["provider"] != providerName {
+		return nil, errors.New("授权请求无效或已过期，请重新登录")
+	}
+
+	if service.Error != "" {
+		return nil, fmt.Errorf("身份提供方返回错误: %s %s", service.Error, service.ErrorDescription)
+	}
+	if service.Code == "" {
+		return nil, errors.New("缺少授权码")
+	}
+
+	providerConfig, provider, err := getOIDCProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResponse, err := provider.Exchange(service.Code, state["code_verifier"])
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.VerifyIDToken(tokenResponse.IDToken, state["nonce"])
+	if err != nil {
+		return nil, err
+	}
+
+	var oidcService OIDCService
+	user, err := oidcService.provisionUser(providerConfig, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被锁定的账号也不能通过身份提供方登录
+	limiter := &LoginLimiter{Username: *user.Username, ClientIP: service.ClientIP, UserAgent: service.UserAgent}
+	if err := limiter.Check(); err != nil {
+		return nil, err
+	}
+
+	return completeLogin(user, limiter)
+}
+
+// provisionUser 根据外部身份查找绑定的用户，未绑定时按配置自动创建，并同步角色
+func (service *OIDCService) provisionUser(providerConfig *config.OIDCProviderConfig, claims jwt.MapClaims) (*model.User, error) {
+	subject, _ := claims["sub"].(string)
+
+	var identity model.UserIdentity
+	err := db.DB.MySQL.Where("provider = ? AND subject = ?", providerConfig.Name, subject).First(&identity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询外部身份失败: %w", err)
+	}
+
+	// 已绑定
+	if err == nil {
+		var user model.User
+		if err := db.DB.MySQL.First(&user, identity.UserID).Error; err != nil {
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+
+		if providerConfig.SyncRoles {
+			roleIDs, err := service.mapRoles(providerConfig, claims)
+			if err != nil {
+				return nil, err
+			}
+
+			var userRoleService UserRoleService
+			if err := db.DB.MySQL.Transaction(func(tx *gorm.DB) error {
+				return userRoleService.SaveUserRoles(tx, user.ID, roleIDs)
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		return &user, nil
+	}
+
+	if !providerConfig.AutoCreate {
+		return nil, errors.New("该外部账号未绑定本系统用户")
+	}
+
+	roleIDs, err := service.mapRoles(providerConfig, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自动创建用户，密码为随机值（只能通过身份提供方登录）
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("生成随机密码失败: %w", err)
+	}
+	hashedPassword, err := utils.EncodePassword(hex.EncodeToString(b))
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := service.uniqueUsername(providerConfig, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username: &username,
+		Password: &hashedPassword,
+		Status:   types.StatusEnabled,
+	}
+	if name, _ := claims["name"].(string); name != "" {
+		user.Nickname = &name
+	}
+
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" {
+		// 邮箱已被其他用户使用时不写入，避免唯一索引冲突，也不自动合并账号
+		var count int64
+		if err := db.DB.MySQL.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询邮箱失败: %w", err)
+		}
+		if count == 0 {
+			user.Email = &email
+			user.EmailVerified, _ = claims["email_verified"].(bool)
+		}
+	}
+
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	if err := tx.Create(user).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+
+	if err := tx.Create(&model.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerConfig.Name,
+		Subject:  subject,
+		Email:    email,
+	}).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("绑定外部身份失败: %w", err)
+	}
+
+	var userRoleService UserRoleService
+	if err := userRoleService.SaveUserRoles(tx, user.ID, roleIDs); err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, err
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	return user, nil
+}
+
+// mapRoles 按配置将身份提供方角色映射为本系统角色ID，并加上默认角色
+func (service *OIDCService) mapRoles(providerConfig *config.OIDCProviderConfig, claims jwt.MapClaims) ([]uint, error) {
+	codes := append([]string{}, providerConfig.DefaultRoles...)
+
+	if providerConfig.RoleClaim != "" {
+		claimRoles := map[string]bool{}
+		for _, role := range claimStrings(claims, providerConfig.RoleClaim) {
+			claimRoles[role] = true
+		}
+		for _, mapping := range providerConfig.RoleMapping {
+			if claimRoles[mapping.Claim] {
+				codes = append(codes, mapping.Role)
+			}
+		}
+	}
+
+	if len(codes) == 0 {
+		return []uint{}, nil
+	}
+
+	var roleIDs []uint
+	if err := db.DB.MySQL.Model(&model.Role{}).Where("code IN ? AND status = ?", codes, types.StatusEnabled).
+		Pluck("id", &roleIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+
+	return roleIDs, nil
+}
+
+// uniqueUsername 从声明中取用户名，已被占用时追加随机后缀
+func (service *OIDCService) uniqueUsername(providerConfig *config.OIDCProviderConfig, claims jwt.MapClaims) (string, error) {
+	usernameClaim := providerConfig.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = "preferred_username"
+	}
+
+	username, _ := claims[usernameClaim].(string)
+	if username == "" {
+		email, _ := claims["email"].(string)
+		username, _, _ = strings.Cut(email, "@")
+	}
+	// 用户名不能是邮箱或手机号格式
+	username, _, _ = strings.Cut(username, "@")
+	if utils.IsPhone(username) {
+		username = providerConfig.Name + "_" + username
+	}
+	if username == "" {
+		username = providerConfig.Name + "_" + claims["sub"].(string)
+	}
+	if len(username) > 40 {
+		username = username[:40]
+	}
+
+	candidate := username
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := db.DB.MySQL.Model(&model.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("查询用户名失败: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		candidate = username + "_" + hex.EncodeToString(b)
+	}
+
+	return "", errors.New("生成用户名失败")
+}
+
+// getOIDCProvider 根据名称获取身份提供方配置和实例
+func getOIDCProvider(name string) (*config.OIDCProviderConfig, *oidc.Provider, error) {
+	for i := range config.GlobalConfig.OIDC.Providers {
+		providerConfig := &config.GlobalConfig.OIDC.Providers[i]
+		if providerConfig.Name != name {
+			continue
+		}
+
+		if provider, ok := oidcProviders.Load(name); ok {
+			return providerConfig, provider.(*oidc.Provider), nil
+		}
+
+		provider, _ := oidcProviders.LoadOrStore(name, oidc.NewProvider(oidc.Config{
+			Issuer:       providerConfig.Issuer,
+			ClientID:     providerConfig.ClientID,
+			ClientSecret: providerConfig.ClientSecret,
+			RedirectURL:  providerConfig.RedirectURL,
+			Scopes:       providerConfig.Scopes,
+		}, nil))
+		return providerConfig, provider.(*oidc.Provider), nil
+	}
+
+	return nil, nil, fmt.Errorf("身份提供方 %s 不存在", name)
+}
+
+// claimStrings 按 a.b.c 路径读取声明，返回字符串列表
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	var value any = map[string]any(claims)
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/oidc/oidctest"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcProviderSeq 身份提供方实例按名称缓存，每次配置使用不同的名称
+var oidcProviderSeq atomic.Int64
+
+// useOIDCProvider 配置一个指向测试身份提供方的身份提供方，返回测试身份提供方和配置的名称
+func useOIDCProvider(t *testing.T) (*oidctest.Server, string) {
+	t.Helper()
+
+	server := oidctest.NewServer("ffly", "secret")
+	t.Cleanup(server.Close)
+	name := fmt.Sprintf("test-%d", oidcProviderSeq.Add(1))
+
+	oidcConfig := config.GlobalConfig.OIDC
+	t.Cleanup(func() { config.GlobalConfig.OIDC = oidcConfig })
+	config.GlobalConfig.OIDC.Providers = []config.OIDCProviderConfig{{
+		Name:         name,
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost/api/v1/oidc/" + name + "/callback",
+	}}
+
+	return server, name
+}
+
+// authorizeOIDC 发起登录并在身份提供方完成授权，返回回调请求和发起登录时写入 Cookie 的 state
+func authorizeOIDC(t *testing.T, server *oidctest.Server, name string, claims jwt.MapClaims) (*service.OIDCCallbackService, string) {
+	t.Helper()
+
+	var oidcService service.OIDCService
+	authURL, state, err := oidcService.AuthURL(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, returnedState, err := server.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &service.OIDCCallbackService{Code: code, State: returnedState, ClientIP: "127.0.0.1"}, state
+}
+
+// TestOIDCCallbackRequiresBrowserState 回调的 state 必须与发起登录的浏览器 Cookie 一致（防止登录 CSRF）
+func TestOIDCCallbackRequiresBrowserState(t *testing.T) {
+	newMockRedis(t)
+	server, provider := useOIDCProvider(t)
+
+	tests := []struct {
+		name         string
+		browserState func(state string) string
+	}{
+		{"没有 Cookie", func(string) string { return "" }},
+		{"Cookie 来自另一次登录", func(string) string { return "other-state" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, state := authorizeOIDC(t, server, provider, jwt.MapClaims{"sub": "attacker"})
+			callback.BrowserState = tt.browserState(state)
+			if _, err := callback.Callback(provider); err == nil {
+				t.Error("state 与浏览器 Cookie 不一致时应拒绝登录")
+			}
+		})
+	}
+}
+
+// TestOIDCCallbackCompletesLogin 身份提供方登录与密码登录一样检查锁定和两步验证
+func TestOIDCCallbackCompletesLogin(t *testing.T) {
+	tests := []struct {
+		name                   string
+		twoFactorEnabled       bool
+		roleRequiresTwoFactor  int
+		locked                 bool
+		wantTwoFactorRequired  bool
+		wantTwoFactorSetupNeed bool
+	}{
+		{name: "已开启两步验证", twoFactorEnabled: true, wantTwoFactorRequired: true},
+		{name: "角色要求两步验证", roleRequiresTwoFactor: 1, wantTwoFactorSetupNeed: true},
+		{name: "账号已锁定", locked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisServer := newMockRedis(t)
+			mock, _ := newMockMySQL(t)
+			useHS256(t)
+			server, provider := useOIDCProvider(t)
+
+			mock.ExpectQuery("SELECT \\* FROM `user_identities`").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "provider", "subject"}).AddRow(1, 7, provider, "u-7"))
+			mock.ExpectQuery("SELECT \\* FROM `users`").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "status", "two_factor_enabled"}).AddRow(7, "alice", 1, tt.twoFactorEnabled))
+			if tt.locked {
+				redisServer.Set("login:lock:user:alice", "1")
+				redisServer.SetTTL("login:lock:user:alice", time.Minute)
+				// 异步记录被锁定的登录事件
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `api_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `roles`").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.roleRequiresTwoFactor))
+			}
+
+			callback, state := authorizeOIDC(t, server, provider, jwt.MapClaims{"sub": "u-7"})
+			callback.BrowserState = state
+			result, err := callback.Callback(provider)
+			if tt.locked {
+				if err == nil {
+					t.Error("被锁定的账号不能通过身份提供方登录")
+				}
+				waitExpectations(t, mock)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.TokenPair != nil || result.ChallengeToken == "" {
+				t.Errorf("需要两步验证时不应签发 Token 对: %+v", result)
+			}
+			if result.TwoFactorRequired != tt.wantTwoFactorRequired || result.TwoFactorSetupRequired != tt.wantTwoFactorSetupNeed {
+				t.Errorf("结果 = %+v", result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
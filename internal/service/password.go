@@ -0,0 +1,213 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/mail"
+	types "ffly-baisc/pkg/type"
+	"ffly-baisc/pkg/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetKey      = "password_reset:%s"       // 重置 Token 哈希 -> 用户ID
+	passwordResetUserKey  = "password_reset_user:%d"  // 用户当前有效的重置 Token 哈希
+	passwordResetLimitKey = "password_reset_limit:%s" // 邮箱发送频率限制
+
+	defaultPasswordResetExpire = 30 * time.Minute
+	passwordResetInterval      = time.Minute // 同一邮箱一分钟内只发送一次
+)
+
+var errResetTokenInvalid = errors.New("重置链接无效或已过期")
+
+// consumeResetTokenScript 使用重置 Token：Token 仍属于该用户时删除 Token 及用户当前 Token 记录
+// KEYS[1] 重置 Token，KEYS[2] 用户当前 Token；ARGV[1] 用户ID，ARGV[2] Token 哈希
+var consumeResetTokenScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+if redis.call('GET', KEYS[2]) == ARGV[2] then
+	redis.call('DEL', KEYS[2])
+end
+return 1
+`)
+
+// ForgotPasswordService 忘记密码，发送重置邮件
+type ForgotPasswordService struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Forgot 生成重置 Token 并发送邮件
+// 无论邮箱是否存在都返回成功，避免泄露用户信息
+func (service *ForgotPasswordService) Forgot() error {
+	email := strings.ToLower(strings.TrimSpace(service.Email))
+
+	// 发送频率限制
+	ok, err := db.DB.Redis.SetNX(fmt.Sprintf(passwordResetLimitKey, email), 1, passwordResetInterval).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("发送过于频繁，请稍后再试")
+	}
+
+	var user model.User
+	if err := db.DB.MySQL.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// 禁用用户和目录用户不发送重置邮件
+	if user.Status == types.StatusDisabled || AuthSourceOf(&user) != AuthSourceLocal {
+		return nil
+	}
+
+	// 生成重置 Token，Redis 中只保存哈希值
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("生成重置 Token 失败: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	tokenHash := hashResetToken(token)
+
+	expire := passwordResetExpire()
+	userKey := fmt.Sprintf(passwordResetUserKey, user.ID)
+
+	// 作废该用户之前的重置 Token
+	oldTokenHash, err := db.DB.Redis.Get(userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := db.DB.Redis.TxPipeline()
+	if oldTokenHash != "" {
+		pipe.Del(fmt.Sprintf(passwordResetKey, oldTokenHash))
+	}
+	pipe.Set(fmt.Sprintf(passwordResetKey, tokenHash), user.ID, expire)
+	pipe.Set(userKey, tokenHash, expire)
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("保存重置 Token 失败: %w", err)
+	}
+
+	msg := &mail.Message{
+		To:      []string{*user.Email},
+		Subject: fmt.Sprintf("[%s] 重置密码", config.GlobalConfig.App.Name),
+		Body: fmt.Sprintf("您好 %s：\n\n请在 %d 分钟内点击以下链接重置密码：\n%s\n\n如果这不是您本人的操作，请忽略此邮件。\n",
+			*user.Username, int(expire.Minutes()), fmt.Sprintf(config.GlobalConfig.Password.ResetURL, token)),
+	}
+
+	// 异步发送邮件，避免通过响应时间判断邮箱是否存在
+	go func(msg *mail.Message) {
+		if err := mail.NewMailer().Send(msg); err != nil {
+			log.Printf("Failed to send password reset mail: %v\n", err)
+		}
+	}(msg)
+
+	return nil
+}
+
+// ResetPasswordService 使用重置 Token 设置新密码
+type ResetPasswordService struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+	PasswordConfirm string `json:"passwordConfirm" binding:"required"`
+}
+
+// Reset 校验重置 Token 并修改密码，Token 使用一次后失效
+func (service *ResetPasswordService) Reset() error {
+	if service.NewPassword != service.PasswordConfirm {
+		return errors.New("新密码和确认密码不匹配")
+	}
+
+	// 先读取重置 Token，密码校验通过后再使用，避免密码不符合策略时链接失效
+	tokenHash := hashResetToken(service.Token)
+	tokenKey := fmt.Sprintf(passwordResetKey, tokenHash)
+	userID, err := db.DB.Redis.Get(tokenKey).Uint64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return errResetTokenInvalid
+		}
+		return err
+	}
+
+	var user model.User
+	if err := db.DB.MySQL.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if AuthSourceOf(&user) != AuthSourceLocal {
+		return errDirectoryPassword
+	}
+
+	// 校验密码策略
+	var passwordPolicyService PasswordPolicyService
+	if err := passwordPolicyService.Validate(user.ID, *user.Username, service.NewPassword); err != nil {
+		return err
+	}
+
+	// 原子地使用重置 Token，并发请求中只有一个能成功，保证只能使用一次
+	used, err := consumeResetTokenScript.Run(db.DB.Redis,
+		[]string{tokenKey, fmt.Sprintf(passwordResetUserKey, userID)}, userID, tokenHash).Int()
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return errResetTokenInvalid
+	}
+
+	// 加密密码
+	hashedPassword, err := utils.EncodePassword(service.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	// 更新密码并记录历史密码
+	if err := passwordPolicyService.SavePassword(tx, user.ID, hashedPassword); err != nil {
+		tx.Rollback() // 回滚事务
+		return err
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	// 注销用户的所有登录会话
+	var tokenService TokenService
+	return tokenService.RevokeUserTokens(uint(userID))
+}
+
+// passwordResetExpire 重置 Token 有效期
+func passwordResetExpire() time.Duration {
+	if config.GlobalConfig.Password.ResetExpire <= 0 {
+		return defaultPasswordResetExpire
+	}
+	return time.Duration(config.GlobalConfig.Password.ResetExpire) * time.Second
+}
+
+// hashResetToken 计算重置 Token 哈希
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/utils"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// PasswordPolicyService 密码策略服务，创建用户、注册、修改密码、重置密码统一使用
+type PasswordPolicyService struct{}
+
+// Validate 校验密码是否符合策略，userID 为 0 表示新用户（不校验历史密码）
+func (service *PasswordPolicyService) Validate(userID uint, username, password string) error {
+	policy := config.GlobalConfig.Password
+
+	if policy.MinLength > 0 && utf8.RuneCountInString(password) < policy.MinLength {
+		return fmt.Errorf("密码长度不能少于 %d 位", policy.MinLength)
+	}
+	if len(password) > 72 {
+		// bcrypt 只使用前 72 字节
+		return errors.New("密码长度不能超过 72 字节")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUpper && !hasUpper {
+		return errors.New("密码必须包含大写字母")
+	}
+	if policy.RequireLower && !hasLower {
+		return errors.New("密码必须包含小写字母")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return errors.New("密码必须包含数字")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return errors.New("密码必须包含特殊字符")
+	}
+
+	// 禁用密码
+	lowerPassword := strings.ToLower(password)
+	for _, banned := range policy.BannedPasswords {
+		if lowerPassword == strings.ToLower(banned) {
+			return errors.New("密码过于简单，请更换")
+		}
+	}
+	if username != "" && strings.Contains(lowerPassword, strings.ToLower(username)) {
+		return errors.New("密码不能包含用户名")
+	}
+
+	// 历史密码
+	if userID != 0 && policy.HistorySize > 0 {
+		var histories []*model.PasswordHistory
+		if err := db.DB.MySQL.Where("user_id = ?", userID).Order("id DESC").Limit(policy.HistorySize).Find(&histories).Error; err != nil {
+			return fmt.Errorf("查询历史密码失败: %w", err)
+		}
+		for _, history := range histories {
+			if utils.CheckPassword(history.Password, password) {
+				return fmt.Errorf("不能使用最近 %d 次使用过的密码", policy.HistorySize)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SavePassword 保存新密码（已加密），记录历史密码并清除“必须修改密码”标记
+func (service *PasswordPolicyService) SavePassword(tx *gorm.DB, userID uint, hashedPassword string) error {
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"password":             hashedPassword,
+		"password_changed_at":  time.Now(),
+		"must_change_password": false,
+	}).Error; err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+
+	return service.RecordHistory(tx, userID, hashedPassword)
+}
+
+// RecordHistory 记录历史密码，只保留最近 history_size 条
+func (service *PasswordPolicyService) RecordHistory(tx *gorm.DB, userID uint, hashedPassword string) error {
+	historySize := config.GlobalConfig.Password.HistorySize
+	if historySize <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&model.PasswordHistory{UserID: userID, Password: hashedPassword}).Error; err != nil {
+		return fmt.Errorf("记录历史密码失败: %w", err)
+	}
+
+	// 删除多余的历史密码 需要硬删除
+	var keepIDs []uint
+	if err := tx.Model(&model.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("id DESC").Limit(historySize).Pluck("id", &keepIDs).Error; err != nil {
+		return fmt.Errorf("查询历史密码失败: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND id NOT IN ?", userID, keepIDs).Unscoped().Delete(&model.PasswordHistory{}).Error; err != nil {
+		return fmt.Errorf("删除历史密码失败: %w", err)
+	}
+
+	return nil
+}
+
+// MustChangePassword 用户是否需要修改密码（管理员要求修改或密码已过期）
+// 目录用户的密码由目录服务管理，不适用本系统的密码策略
+func (service *PasswordPolicyService) MustChangePassword(user *model.User) bool {
+	if AuthSourceOf(user) != AuthSourceLocal {
+		return false
+	}
+	if user.MustChangePassword {
+		return true
+	}
+
+	maxAge := config.GlobalConfig.Password.MaxAge
+	if maxAge <= 0 || user.PasswordChangedAt == nil {
+		return false
+	}
+
+	return time.Since(*user.PasswordChangedAt) > time.Duration(maxAge)*24*time.Hour
+}
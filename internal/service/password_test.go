@@ -0,0 +1,63 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/service"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestResetPasswordKeepsTokenOnPolicyError 新密码不符合策略时重置链接仍然有效，成功重置后才失效
+func TestResetPasswordKeepsTokenOnPolicyError(t *testing.T) {
+	server := newMockRedis(t)
+	mock, _ := newMockMySQL(t)
+
+	password := config.GlobalConfig.Password
+	t.Cleanup(func() { config.GlobalConfig.Password = password })
+	config.GlobalConfig.Password.MinLength = 10
+	config.GlobalConfig.Password.HistorySize = 0
+
+	const token = "reset-token"
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+	server.Set("password_reset:"+tokenHash, "7")
+	server.Set("password_reset_user:7", tokenHash)
+
+	userRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "username", "status"}).AddRow(7, "alice", 1)
+	}
+	reset := func(newPassword string) error {
+		resetService := service.ResetPasswordService{Token: token, NewPassword: newPassword, PasswordConfirm: newPassword}
+		return resetService.Reset()
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(userRows())
+	if err := reset("short"); err == nil {
+		t.Fatal("密码不符合策略时应失败")
+	}
+	if !server.Exists("password_reset:"+tokenHash) || !server.Exists("password_reset_user:7") {
+		t.Fatal("密码不符合策略时不应使用重置 Token")
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(userRows())
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := reset("a-long-password"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("password_reset:"+tokenHash) || server.Exists("password_reset_user:7") {
+		t.Error("重置成功后重置 Token 应失效")
+	}
+
+	// 再次使用时在查询用户前拒绝
+	if err := reset("another-long-password"); err == nil {
+		t.Error("重置 Token 只能使用一次")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
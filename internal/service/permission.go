@@ -1,17 +1,45 @@
 package service
 
 import (
+	"errors"
 	"ffly-baisc/internal/db"
 	"ffly-baisc/internal/model"
 	"ffly-baisc/pkg/file"
 	"ffly-baisc/pkg/query"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type PermissionService struct{}
 
+// ErrPermissionCodeRequired 按钮权限（没有路由路径）必须填写权限码
+var ErrPermissionCodeRequired = errors.New("按钮权限的权限码不能为空")
+
+// normalizePermissionKey 路由路径和权限码有唯一索引，空值统一保存为 NULL
+func normalizePermissionKey(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+
+	return &trimmed
+}
+
+// validatePermissionKey 校验路由路径和权限码，没有路由路径的为按钮权限，必须填写权限码
+func validatePermissionKey(path, code *string) error {
+	if path == nil && code == nil {
+		return ErrPermissionCodeRequired
+	}
+
+	return nil
+}
+
 // BuildPermissionTree 构建权限树
 func (service *PermissionService) BuildPermissionTree(permissions []*model.Permission, parentID uint) []*model.Permission {
 	var trees []*model.Permission
@@ -80,7 +108,8 @@ func (service *PermissionService) CreatePermission(permissionCreatedRequest *mod
 	permission := &model.Permission{
 		Title:     permissionCreatedRequest.Title,
 		Name:      permissionCreatedRequest.Name,
-		Path:      permissionCreatedRequest.Path,
+		Path:      normalizePermissionKey(permissionCreatedRequest.Path),
+		Code:      normalizePermissionKey(permissionCreatedRequest.Code),
 		Component: permissionCreatedRequest.Component,
 		Redirect:  permissionCreatedRequest.Redirect,
 		Visible:   permissionCreatedRequest.Visible,
@@ -97,6 +126,9 @@ func (service *PermissionService) CreatePermission(permissionCreatedRequest *mod
 	if permission.Status == 0 {
 		permission.Status = 1
 	}
+	if err := validatePermissionKey(permission.Path, permission.Code); err != nil {
+		return err
+	}
 
 	if err := db.DB.MySQL.Create(permission).Error; err != nil {
 		return fmt.Errorf("创建权限失败: %w", err)
@@ -119,7 +151,8 @@ func (service *PermissionService) PutPermission(id uint, permissionCreatedReques
 	permission := &model.Permission{
 		Title:     permissionCreatedRequest.Title,
 		Name:      permissionCreatedRequest.Name,
-		Path:      permissionCreatedRequest.Path,
+		Path:      normalizePermissionKey(permissionCreatedRequest.Path),
+		Code:      normalizePermissionKey(permissionCreatedRequest.Code),
 		Component: permissionCreatedRequest.Component,
 		Redirect:  permissionCreatedRequest.Redirect,
 		Visible:   permissionCreatedRequest.Visible,
@@ -136,6 +169,9 @@ func (service *PermissionService) PutPermission(id uint, permissionCreatedReques
 	if permission.Status == 0 {
 		permission.Status = 1
 	}
+	if err := validatePermissionKey(permission.Path, permission.Code); err != nil {
+		return err
+	}
 
 	// 全量更新，使用 Save 方法
 	if err := db.DB.MySQL.Model(&model.Permission{}).Where("id = ?", id).Save(permission).Error; err != nil {
@@ -147,13 +183,44 @@ func (service *PermissionService) PutPermission(id uint, permissionCreatedReques
 
 // PatchPermission 修改菜单
 func (service *PermissionService) PatchPermission(id uint, permissionPatchRequest *model.PermissionPatchRequest) error {
-	// 直接更新并检查是否存在
-	// 状态验证是自动的，通过 UnmarshalJSON 实现
-	if err := db.DB.MySQL.Model(&model.Permission{}).Where("id = ?", id).Updates(permissionPatchRequest).Error; err != nil {
-		return fmt.Errorf("更新菜单失败: %w", err)
+	permission, err := service.GetPermissionByID(id)
+	if err != nil {
+		return fmt.Errorf("查询菜单失败: %w", err)
 	}
 
-	return nil
+	// 路由路径和权限码传空字符串表示清空，保存为 NULL
+	var nullColumns []string
+	if permissionPatchRequest.Path != nil {
+		permissionPatchRequest.Path = normalizePermissionKey(permissionPatchRequest.Path)
+		permission.Path = permissionPatchRequest.Path
+		if permission.Path == nil {
+			nullColumns = append(nullColumns, "path")
+		}
+	}
+	if permissionPatchRequest.Code != nil {
+		permissionPatchRequest.Code = normalizePermissionKey(permissionPatchRequest.Code)
+		permission.Code = permissionPatchRequest.Code
+		if permission.Code == nil {
+			nullColumns = append(nullColumns, "code")
+		}
+	}
+	if err := validatePermissionKey(permission.Path, permission.Code); err != nil {
+		return err
+	}
+
+	// 状态验证是自动的，通过 UnmarshalJSON 实现
+	return db.DB.MySQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Permission{}).Where("id = ?", id).Updates(permissionPatchRequest).Error; err != nil {
+			return fmt.Errorf("更新菜单失败: %w", err)
+		}
+		for _, column := range nullColumns {
+			if err := tx.Model(&model.Permission{}).Where("id = ?", id).Update(column, nil).Error; err != nil {
+				return fmt.Errorf("更新菜单失败: %w", err)
+			}
+		}
+
+		return nil
+	})
 }
 
 // ExportPermission 导出菜单
@@ -172,7 +239,7 @@ func (service *PermissionService) ExportPermission(c *gin.Context) error {
 		{Title: "权限名称", Field: "Name", Width: 20, Prefix: "-->"},
 		// {Title: "权限类型", Field: "Type", Width: 20},
 		{Title: "路由路径", Field: "Path", Width: 20},
-		// {Title: "权限码", Field: "Code", Width: 20},
+		{Title: "权限码", Field: "Code", Width: 20},
 		{Title: "组件名称", Field: "Component", Width: 20},
 		{Title: "图标", Field: "Icon", Width: 20},
 		{Title: "排序", Field: "Sort", Width: 20},
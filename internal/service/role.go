@@ -19,14 +19,21 @@ func (service *RoleService) GetRoleList(c *gin.Context) ([]*model.Role, *query.P
 		return nil, nil, err
 	}
 
-	// 为每个角色填充权限IDs
+	// 批量填充角色的权限IDs
+	roleIDs := make([]uint, 0, len(*roles))
+	for _, role := range *roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
 	var rolePermissionService RolePermissionService
+	permissionIDs, err := rolePermissionService.GetRolePermissionIdsByRoleIDs(db.DB.MySQL, roleIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取角色权限失败: %w", err)
+	}
 	for _, role := range *roles {
-		permissionIDs, err := rolePermissionService.GetRolePermissionIds(db.DB.MySQL, role.ID)
-		if err != nil {
-			return nil, nil, fmt.Errorf("获取角色权限失败: %w", err)
+		role.PermissionIDs = permissionIDs[role.ID]
+		if role.PermissionIDs == nil {
+			role.PermissionIDs = []uint{}
 		}
-		role.PermissionIDs = permissionIDs
 	}
 
 	return *roles, pagination, nil
@@ -54,11 +61,12 @@ func (service *RoleService) GetRoleByID(id uint) (*model.Role, error) {
 func (service *RoleService) CreateRole(roleCreateRequest *model.RoleCreateRequest) error {
 	// 将请求数据转换为Role模型
 	role := &model.Role{
-		Name:      *roleCreateRequest.Name,
-		Code:      *roleCreateRequest.Code,
-		Remark:    *roleCreateRequest.Remark,
-		Status:    roleCreateRequest.Status,
-		BaseModel: roleCreateRequest.BaseModel,
+		Name:             *roleCreateRequest.Name,
+		Code:             *roleCreateRequest.Code,
+		Remark:           *roleCreateRequest.Remark,
+		Status:           roleCreateRequest.Status,
+		RequireTwoFactor: roleCreateRequest.RequireTwoFactor,
+		BaseModel:        roleCreateRequest.BaseModel,
 	}
 
 	if err := db.DB.MySQL.Create(role).Error; err != nil {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/query"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -66,3 +67,13 @@ func (service *RolePermissionService) GetRolePermissionIds(tx *gorm.DB, roleID u
 
 	return rolePermissionsIDs, nil
 }
+
+// GetRolePermissionIdsByRoleIDs 批量获取多个角色的权限ids，一次查询，返回 角色ID -> 权限ID列表
+func (service *RolePermissionService) GetRolePermissionIdsByRoleIDs(tx *gorm.DB, roleIDs []uint) (map[uint][]uint, error) {
+	permissionIDs, err := query.LoadJoinIDs(tx, &model.RolePermission{}, "role_id", "permission_id", roleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色权限失败: %w", err)
+	}
+
+	return permissionIDs, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/query"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SavedViewService 列表视图服务，视图可以共享给角色，列表通过 view=<id> 参数使用
+type SavedViewService struct{}
+
+// savedViewResources 支持保存视图的列表，key 为表名，视图按对应模型的白名单校验
+var savedViewResources = map[string]any{
+	"users":         &model.User{},
+	"roles":         &model.Role{},
+	"oauth_clients": &model.OAuthClient{},
+	"api_logs":      &model.ApiLog{},
+}
+
+// GetSavedViews 获取用户可以使用的视图：自己创建的和共享给自己角色的，resource 为空时返回所有列表的视图
+func (service *SavedViewService) GetSavedViews(userID uint, resource string) ([]*model.SavedView, error) {
+	roleIDs, err := service.userRoleIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.DB.MySQL.Where("user_id = ? OR role_id IN (?)", userID, roleIDs)
+	if resource != "" {
+		tx = tx.Where("resource = ?", resource)
+	}
+
+	var views []*model.SavedView
+	if err := tx.Order("id DESC").Find(&views).Error; err != nil {
+		return nil, fmt.Errorf("查询视图失败: %w", err)
+	}
+
+	return views, nil
+}
+
+// CreateSavedView 创建视图
+func (service *SavedViewService) CreateSavedView(userID uint, savedViewCreateRequest *model.SavedViewCreateRequest) (*model.SavedView, error) {
+	view := &model.SavedView{
+		UserID:   userID,
+		Resource: savedViewCreateRequest.Resource,
+		Name:     savedViewCreateRequest.Name,
+		Params:   normalizeViewParams(savedViewCreateRequest.Params),
+		Sort:     savedViewCreateRequest.Sort,
+		Fields:   savedViewCreateRequest.Fields,
+		RoleID:   savedViewCreateRequest.RoleID,
+	}
+	if err := service.validate(userID, view); err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.MySQL.Create(view).Error; err != nil {
+		return nil, fmt.Errorf("创建视图失败: %w", err)
+	}
+
+	return view, nil
+}
+
+// PatchSavedView 部分更新视图，只有创建者可以修改
+func (service *SavedViewService) PatchSavedView(userID, id uint, savedViewPatchRequest *model.SavedViewPatchRequest) (*model.SavedView, error) {
+	view, err := service.getOwnSavedView(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if savedViewPatchRequest.Name != nil {
+		view.Name = *savedViewPatchRequest.Name
+	}
+	if savedViewPatchRequest.Params != nil {
+		view.Params = normalizeViewParams(savedViewPatchRequest.Params)
+	}
+	if savedViewPatchRequest.Sort != nil {
+		view.Sort = *savedViewPatchRequest.Sort
+	}
+	if savedViewPatchRequest.Fields != nil {
+		view.Fields = savedViewPatchRequest.Fields
+	}
+	if savedViewPatchRequest.RoleID != nil {
+		view.RoleID = savedViewPatchRequest.RoleID
+		if *savedViewPatchRequest.RoleID == 0 {
+			view.RoleID = nil // 取消共享
+		}
+	}
+	if err := service.validate(userID, view); err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.MySQL.Select("name", "params", "sort", "fields", "role_id").Updates(view).Error; err != nil {
+		return nil, fmt.Errorf("更新视图失败: %w", err)
+	}
+
+	return view, nil
+}
+
+// DeleteSavedView 删除视图，只有创建者可以删除
+func (service *SavedViewService) DeleteSavedView(userID, id uint) error {
+	result := db.DB.MySQL.Where("id = ? AND user_id = ?", id, userID).Delete(&model.SavedView{})
+	if result.Error != nil {
+		return fmt.Errorf("删除视图失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("视图不存在")
+	}
+
+	return nil
+}
+
+// LoadSavedView 列表 view 参数的视图加载器，只能使用自己创建的或共享给自己角色的视图
+func LoadSavedView(c *gin.Context, resource string, id uint) (*query.View, error) {
+	var service SavedViewService
+	userID := c.GetUint("userID")
+
+	var view model.SavedView
+	if err := db.DB.MySQL.Where("id = ? AND resource = ?", id, resource).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &query.ParamError{Message: "视图不存在"}
+		}
+		return nil, fmt.Errorf("查询视图失败: %w", err)
+	}
+
+	if view.UserID != userID {
+		roleIDs, err := service.userRoleIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+		if view.RoleID == nil || !slices.Contains(roleIDs, *view.RoleID) {
+			return nil, &query.ParamError{Message: "视图不存在"}
+		}
+	}
+
+	return &query.View{Params: string(view.Params), Sort: view.Sort, Fields: view.Fields}, nil
+}
+
+// getOwnSavedView 获取用户自己创建的视图
+func (service *SavedViewService) getOwnSavedView(userID, id uint) (*model.SavedView, error) {
+	var view model.SavedView
+	if err := db.DB.MySQL.Where("id = ? AND user_id = ?", id, userID).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("视图不存在")
+		}
+		return nil, fmt.Errorf("查询视图失败: %w", err)
+	}
+
+	return &view, nil
+}
+
+// validate 按列表模型的白名单校验视图，并校验共享的角色
+func (service *SavedViewService) validate(userID uint, view *model.SavedView) error {
+	resourceModel, ok := savedViewResources[view.Resource]
+	if !ok {
+		return fmt.Errorf("不支持的列表 %s", view.Resource)
+	}
+
+	if err := query.ValidateView(db.DB.MySQL, resourceModel, &query.View{
+		Params: string(view.Params),
+		Sort:   view.Sort,
+		Fields: view.Fields,
+	}); err != nil {
+		return err
+	}
+
+	if view.RoleID == nil {
+		return nil
+	}
+
+	// 超级管理员可以共享给任意角色，其他用户只能共享给自己拥有的角色
+	var authService AuthPermissionService
+	isSuperAdmin, err := authService.IsSuperAdmin(userID)
+	if err != nil {
+		return err
+	}
+	if isSuperAdmin {
+		if err := db.DB.MySQL.First(&model.Role{}, *view.RoleID).Error; err != nil {
+			return errors.New("共享的角色不存在")
+		}
+		return nil
+	}
+
+	roleIDs, err := service.userRoleIDs(userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(roleIDs, *view.RoleID) {
+		return errors.New("只能共享给自己拥有的角色")
+	}
+
+	return nil
+}
+
+// userRoleIDs 获取用户的角色ID列表
+func (service *SavedViewService) userRoleIDs(userID uint) ([]uint, error) {
+	var userRoleService UserRoleService
+	userRoles, err := userRoleService.GetRolesByUserID(db.DB.MySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := make([]uint, 0, len(userRoles))
+	for _, userRole := range userRoles {
+		roleIDs = append(roleIDs, userRole.RoleID)
+	}
+
+	return roleIDs, nil
+}
+
+// normalizeViewParams 空的查询条件统一保存为 NULL
+func normalizeViewParams(params []byte) []byte {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+
+	return params
+}
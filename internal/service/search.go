@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/query"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchService 全局搜索服务，在用户、角色和权限中按关键词搜索
+// 使用 LIKE 匹配，在 SQL 中按匹配程度计算相关度并排序，不依赖全文索引，本地数据库即可使用
+type SearchService struct{}
+
+const (
+	searchMaxQueryLength = 100 // 搜索关键词最大长度
+	searchMaxTerms       = 5   // 最多支持的关键词个数
+
+	// 匹配程度得分，再乘以字段权重
+	searchExactScore    = 100 // 完全相同
+	searchPrefixScore   = 60  // 前缀匹配
+	searchContainsScore = 30  // 包含
+)
+
+// searchTypes 可搜索的数据类型及查看所需的权限码，与对应的列表接口一致
+var searchTypes = []struct {
+	Type       string
+	Permission string
+}{
+	{"user", "user:list"},
+	{"role", "role:list"},
+	{"permission", "permission:list"},
+}
+
+// searchField 参与匹配的字段
+type searchField struct {
+	Name   string // 字段名（数据库列名）
+	Weight int    // 权重，百分比
+}
+
+// 各类数据参与匹配的字段及权重
+var (
+	searchUserFields       = []searchField{{"username", 100}, {"nickname", 90}, {"email", 80}, {"phone", 80}}
+	searchRoleFields       = []searchField{{"code", 100}, {"name", 100}}
+	searchPermissionFields = []searchField{{"title", 100}, {"code", 90}, {"path", 80}}
+)
+
+// ParseSearchTerms 解析搜索关键词，多个关键词使用空格分隔，每个关键词都需要匹配
+func (service *SearchService) ParseSearchTerms(q string) ([]string, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, errors.New("搜索关键词不能为空")
+	}
+	if utf8.RuneCountInString(q) > searchMaxQueryLength {
+		return nil, fmt.Errorf("搜索关键词不能超过 %d 个字符", searchMaxQueryLength)
+	}
+
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(q)) {
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) > searchMaxTerms {
+		return nil, fmt.Errorf("最多支持 %d 个关键词", searchMaxTerms)
+	}
+
+	return terms, nil
+}
+
+// AllowedTypes 根据用户的权限码获取可以搜索的数据类型
+func (service *SearchService) AllowedTypes(codes []string) []string {
+	var types []string
+	for _, searchType := range searchTypes {
+		if slices.Contains(codes, searchType.Permission) {
+			types = append(types, searchType.Type)
+		}
+	}
+
+	return types
+}
+
+// Search 在指定类型的数据中搜索，结果按相关度降序，相关度相同时按类型和ID排序
+// 每类数据在 SQL 中取相关度最高的 limit 条，合并后再取前 limit 条
+func (service *SearchService) Search(types, terms []string, limit int) ([]*model.SearchResult, error) {
+	var results []*model.SearchResult
+	for _, searchType := range types {
+		var items []*model.SearchResult
+		var err error
+		switch searchType {
+		case "user":
+			items, err = service.searchUsers(terms, limit)
+		case "role":
+			items, err = service.searchRoles(terms, limit)
+		case "permission":
+			items, err = service.searchPermissions(terms, limit)
+		default:
+			return nil, fmt.Errorf("不支持搜索 %s", searchType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, items...)
+	}
+
+	slices.SortStableFunc(results, func(a, b *model.SearchResult) int {
+		return b.Score - a.Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// searchUsers 搜索用户名、昵称、邮箱和手机号
+func (service *SearchService) searchUsers(terms []string, limit int) ([]*model.SearchResult, error) {
+	var rows []struct {
+		ID       uint
+		Username *string
+		Nickname *string
+		Email    *string
+		Phone    *string
+		Score    int
+	}
+	if err := searchQuery(db.DB.MySQL.Model(&model.User{}), terms, searchUserFields, limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("搜索用户失败: %w", err)
+	}
+
+	results := make([]*model.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		username, nickname := stringValue(row.Username), stringValue(row.Nickname)
+		results = append(results, &model.SearchResult{
+			Type:     "user",
+			ID:       row.ID,
+			Title:    username,
+			Subtitle: nickname,
+			Field:    searchMatchedField(terms, searchUserFields, username, nickname, stringValue(row.Email), stringValue(row.Phone)),
+			Score:    row.Score,
+		})
+	}
+
+	return results, nil
+}
+
+// searchRoles 搜索角色名称和编码
+func (service *SearchService) searchRoles(terms []string, limit int) ([]*model.SearchResult, error) {
+	var rows []struct {
+		ID    uint
+		Code  string
+		Name  string
+		Score int
+	}
+	if err := searchQuery(db.DB.MySQL.Model(&model.Role{}), terms, searchRoleFields, limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("搜索角色失败: %w", err)
+	}
+
+	results := make([]*model.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &model.SearchResult{
+			Type:     "role",
+			ID:       row.ID,
+			Title:    row.Name,
+			Subtitle: row.Code,
+			Field:    searchMatchedField(terms, searchRoleFields, row.Code, row.Name),
+			Score:    row.Score,
+		})
+	}
+
+	return results, nil
+}
+
+// searchPermissions 搜索权限标题、权限码和路由路径
+func (service *SearchService) searchPermissions(terms []string, limit int) ([]*model.SearchResult, error) {
+	var rows []struct {
+		ID    uint
+		Title string
+		Code  *string
+		Path  *string
+		Score int
+	}
+	if err := searchQuery(db.DB.MySQL.Model(&model.Permission{}), terms, searchPermissionFields, limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("搜索权限失败: %w", err)
+	}
+
+	results := make([]*model.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		code, path := stringValue(row.Code), stringValue(row.Path)
+		subtitle := code
+		if subtitle == "" {
+			subtitle = path // 菜单没有权限码，显示路由路径
+		}
+		results = append(results, &model.SearchResult{
+			Type:     "permission",
+			ID:       row.ID,
+			Title:    row.Title,
+			Subtitle: subtitle,
+			Field:    searchMatchedField(terms, searchPermissionFields, row.Title, code, path),
+			Score:    row.Score,
+		})
+	}
+
+	return results, nil
+}
+
+// searchQuery 构造搜索语句：每个关键词至少匹配一个字段，按相关度降序、ID 升序取前 limit 条
+func searchQuery(tx *gorm.DB, terms []string, fields []searchField, limit int) *gorm.DB {
+	columns := make([]any, 0, len(fields)+2)
+	placeholders := make([]string, 0, len(fields)+2)
+	columns = append(columns, clause.Column{Table: clause.CurrentTable, Name: "id"})
+	placeholders = append(placeholders, "?")
+	for _, field := range fields {
+		columns = append(columns, clause.Column{Table: clause.CurrentTable, Name: field.Name})
+		placeholders = append(placeholders, "?")
+	}
+	columns = append(columns, searchScoreExpr(terms, fields))
+	placeholders = append(placeholders, "? AS score")
+	tx = tx.Select(strings.Join(placeholders, ", "), columns...)
+
+	for _, term := range terms {
+		pattern := "%" + query.EscapeLike(term) + "%"
+		conditions := make([]clause.Expression, 0, len(fields))
+		for _, field := range fields {
+			conditions = append(conditions, clause.Like{Column: clause.Column{Table: clause.CurrentTable, Name: field.Name}, Value: pattern})
+		}
+		tx = tx.Where(clause.Or(conditions...))
+	}
+
+	return tx.Order("score DESC").Order(clause.OrderByColumn{Column: clause.PrimaryColumn}).Limit(limit)
+}
+
+// searchScoreExpr 构造相关度表达式：每个关键词取匹配程度最高的字段累加，完全相同、前缀、包含分别计分，再乘以字段权重
+// 比较使用数据库的排序规则，默认忽略大小写
+func searchScoreExpr(terms []string, fields []searchField) clause.Expr {
+	var termSQLs []string
+	var vars []any
+	for _, term := range terms {
+		pattern := query.EscapeLike(term)
+		fieldSQLs := make([]string, 0, len(fields))
+		for _, field := range fields {
+			column := clause.Column{Table: clause.CurrentTable, Name: field.Name}
+			fieldSQLs = append(fieldSQLs, "CASE WHEN ? = ? THEN ? WHEN ? LIKE ? THEN ? WHEN ? LIKE ? THEN ? ELSE 0 END")
+			vars = append(vars,
+				column, term, searchExactScore*field.Weight/100,
+				column, pattern+"%", searchPrefixScore*field.Weight/100,
+				column, "%"+pattern+"%", searchContainsScore*field.Weight/100,
+			)
+		}
+
+		termSQL := fieldSQLs[0]
+		if len(fieldSQLs) > 1 {
+			termSQL = "GREATEST(" + strings.Join(fieldSQLs, ", ") + ")"
+		}
+		termSQLs = append(termSQLs, termSQL)
+	}
+
+	return clause.Expr{SQL: "(" + strings.Join(termSQLs, " + ") + ")", Vars: vars}
+}
+
+// searchMatchedField 获取匹配程度最高的字段，values 与 fields 一一对应，只用于展示，相关度以 SQL 计算的为准
+func searchMatchedField(terms []string, fields []searchField, values ...string) string {
+	bestScore, bestField := 0, ""
+	for _, term := range terms {
+		for i, field := range fields {
+			value := strings.ToLower(values[i])
+			fieldScore := 0
+			switch {
+			case value == term:
+				fieldScore = searchExactScore
+			case strings.HasPrefix(value, term):
+				fieldScore = searchPrefixScore
+			case strings.Contains(value, term):
+				fieldScore = searchContainsScore
+			}
+			if fieldScore = fieldScore * field.Weight / 100; fieldScore > bestScore {
+				bestScore, bestField = fieldScore, field.Name
+			}
+		}
+	}
+
+	return bestField
+}
+
+// stringValue 获取字符串指针的值，nil 返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
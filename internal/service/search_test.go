@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"database/sql/driver"
+	"ffly-baisc/internal/service"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	var searchService service.SearchService
+
+	tests := []struct {
+		q       string
+		want    []string
+		wantErr bool
+	}{
+		{q: "  Admin  admin 张三 ", want: []string{"admin", "张三"}},
+		{q: "user:list", want: []string{"user:list"}},
+		{q: "   ", wantErr: true},
+		{q: "a b c d e f", wantErr: true},
+	}
+	for _, tt := range tests {
+		terms, err := searchService.ParseSearchTerms(tt.q)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSearchTerms(%q) err = %v, wantErr %v", tt.q, err, tt.wantErr)
+			continue
+		}
+		if len(terms) != len(tt.want) {
+			t.Errorf("ParseSearchTerms(%q) = %v, 期望 %v", tt.q, terms, tt.want)
+			continue
+		}
+		for i := range terms {
+			if terms[i] != tt.want[i] {
+				t.Errorf("ParseSearchTerms(%q) = %v, 期望 %v", tt.q, terms, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestSearchRankedInSQL 相关度在 SQL 中计算并排序后再取 limit 条，合并各类数据后按相关度降序
+func TestSearchRankedInSQL(t *testing.T) {
+	mock, _ := newMockMySQL(t)
+
+	mock.ExpectQuery("SELECT (.+) AS score FROM `users` WHERE (.+) ORDER BY score DESC,`users`.`id` LIMIT").
+		WithArgs(append(append(
+			// 用户名：完全相同、前缀、包含的得分，其余三个字段同理
+			[]driver.Value{"adm", 100, "adm%", 60, "%adm%", 30}, anyArgs(3*6)...),
+			"%adm%", "%adm%", "%adm%", "%adm%", 2)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "nickname", "email", "phone", "score"}).
+			AddRow(7, "admin2", "管理员", nil, nil, 60).
+			AddRow(3, "xadm", nil, "adm@example.com", nil, 30))
+	mock.ExpectQuery("SELECT (.+) AS score FROM `roles` WHERE (.+) ORDER BY score DESC,`roles`.`id` LIMIT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "name", "score"}).
+			AddRow(1, "adm", "管理员", 100))
+
+	var searchService service.SearchService
+	results, err := searchService.Search([]string{"user", "role"}, []string{"adm"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		Type  string
+		ID    uint
+		Field string
+		Score int
+	}{
+		{"role", 1, "code", 100},
+		{"user", 7, "username", 60},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("结果数量 = %d，期望 %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Type != want[i].Type || result.ID != want[i].ID || result.Field != want[i].Field || result.Score != want[i].Score {
+			t.Errorf("第 %d 条结果 = %+v，期望 %+v", i, *result, want[i])
+		}
+	}
+}
+
+func anyArgs(n int) []driver.Value {
+	args := make([]driver.Value, n)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+
+	return args
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SessionService 登录会话服务，会话即 TokenService 中的令牌族
+type SessionService struct{}
+
+// GetUserSessions 获取用户的登录会话列表，currentID 为当前请求所在的会话ID
+func (service *SessionService) GetUserSessions(userID uint, currentID string) ([]*model.Session, error) {
+	userKey := fmt.Sprintf(tokenUserKey, userID)
+
+	familyIDs, err := db.DB.Redis.SMembers(userKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询用户会话失败: %w", err)
+	}
+
+	sessions := make([]*model.Session, 0, len(familyIDs))
+	for _, familyID := range familyIDs {
+		values, err := db.DB.Redis.HGetAll(fmt.Sprintf(tokenFamilyKey, familyID)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询会话信息失败: %w", err)
+		}
+
+		// 会话已过期，清理集合中的残留记录
+		if len(values) == 0 {
+			db.DB.Redis.SRem(userKey, familyID)
+			continue
+		}
+
+		issuedAt, _ := strconv.ParseInt(values["issued_at"], 10, 64)
+		lastSeen, _ := strconv.ParseInt(values["last_seen"], 10, 64)
+		actorID, _ := strconv.ParseUint(values["actor_id"], 10, 64)
+		sessions = append(sessions, &model.Session{
+			ID:            familyID,
+			UserID:        userID,
+			ClientIP:      values["client_ip"],
+			UserAgent:     values["user_agent"],
+			IssuedAt:      time.Unix(issuedAt, 0),
+			LastSeen:      time.Unix(lastSeen, 0),
+			Current:       familyID == currentID,
+			ActorID:       uint(actorID),
+			ActorUsername: values["actor_username"],
+		})
+	}
+
+	// 按最后活跃时间倒序
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions, nil
+}
+
+// DeleteUserSession 注销用户的某个登录会话
+func (service *SessionService) DeleteUserSession(userID uint, sessionID string) error {
+	// 校验会话是否属于该用户
+	isMember, err := db.DB.Redis.SIsMember(fmt.Sprintf(tokenUserKey, userID), sessionID).Result()
+	if err != nil {
+		return fmt.Errorf("查询用户会话失败: %w", err)
+	}
+	if !isMember {
+		return ErrSessionNotFound
+	}
+
+	var tokenService TokenService
+	return tokenService.RevokeFamily(userID, sessionID)
+}
+
+// DeleteUserSessions 注销用户的所有登录会话，keepID 不为空时保留该会话（用于注销其他设备）
+func (service *SessionService) DeleteUserSessions(userID uint, keepID string) error {
+	var tokenService TokenService
+	if keepID == "" {
+		return tokenService.RevokeUserTokens(userID)
+	}
+
+	familyIDs, err := db.DB.Redis.SMembers(fmt.Sprintf(tokenUserKey, userID)).Result()
+	if err != nil {
+		return fmt.Errorf("查询用户会话失败: %w", err)
+	}
+
+	for _, familyID := range familyIDs {
+		if familyID == keepID {
+			continue
+		}
+		if err := tokenService.RevokeFamily(userID, familyID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
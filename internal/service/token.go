@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/pkg/auth"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// TokenService 令牌存储服务
+// 每次登录产生一个令牌族（family），令牌族在 Redis 中以 hash 存储当前有效的 access/refresh jti，
+// 刷新 Token 时轮换 jti，旧的 Refresh Token 被再次使用时视为泄露，注销整个令牌族
+type TokenService struct{}
+
+const (
+	tokenFamilyKey = "token:family:%s" // hash: user_id, access_jti, refresh_jti, actor_id, actor_username, client_ip, user_agent, issued_at, last_seen
+	tokenUserKey   = "token:user:%d"   // set: 用户的所有令牌族ID
+	tokenActorKey  = "token:actor:%d"  // set: 操作人发起的模拟登录令牌族ID
+)
+
+// ErrSessionNotFound 令牌族不存在或不属于该用户
+var ErrSessionNotFound = errors.New("会话不存在")
+
+// rotateRefreshScript 原子地校验并轮换 Refresh Token，同时延长用户（和操作人）令牌族集合的有效期，
+// 否则一直刷新而不重新登录的会话会在集合过期后脱离集合，无法被 RevokeUserTokens 注销
+// KEYS: 令牌族, 用户令牌族集合, [操作人令牌族集合]
+// ARGV: 旧 refresh_jti, 新 access_jti, 新 refresh_jti, 有效期（秒）, 当前时间, 令牌族ID
+// 返回 1: 轮换成功, 0: Refresh Token 已被使用（重放）, -1: 令牌族不存在（已注销或过期）
+var rotateRefreshScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'refresh_jti')
+if current == false then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'access_jti', ARGV[2])
+redis.call('HSET', KEYS[1], 'refresh_jti', ARGV[3])
+redis.call('HSET', KEYS[1], 'last_seen', ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], ARGV[6])
+	redis.call('EXPIRE', KEYS[i], ARGV[4])
+end
+return 1
+`)
+
+// validateAccessScript 原子地校验 Access Token 并刷新会话最后活跃时间
+// 返回 1: 有效, 0: Access Token 已被轮换, -1: 令牌族不存在（已注销或过期）
+var validateAccessScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'access_jti')
+if current == false then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'last_seen', ARGV[2])
+redis.call('HSET', KEYS[1], 'client_ip', ARGV[3])
+return 1
+`)
+
+// IssueTokenPair 登录成功后签发 Token 对，并创建新的令牌族（登录会话）
+func (service *TokenService) IssueTokenPair(userID uint, username, clientIP, userAgent string) (*auth.TokenPair, error) {
+	tokenPair, err := auth.GenerateTokenPair(userID, username, "")
+	if err != nil {
+		return nil, err
+	}
+
+	familyKey := fmt.Sprintf(tokenFamilyKey, tokenPair.FamilyID)
+	userKey := fmt.Sprintf(tokenUserKey, userID)
+
+	pipe := db.DB.Redis.TxPipeline()
+	pipe.HMSet(familyKey, map[string]interface{}{
+		"user_id":     userID,
+		"access_jti":  tokenPair.AccessTokenID,
+		"refresh_jti": tokenPair.RefreshTokenID,
+		"client_ip":   clientIP,
+		"user_agent":  userAgent,
+		"issued_at":   time.Now().Unix(),
+		"last_seen":   time.Now().Unix(),
+	})
+	pipe.Expire(familyKey, auth.RefreshTokenExpire)
+	pipe.SAdd(userKey, tokenPair.FamilyID)
+	pipe.Expire(userKey, auth.RefreshTokenExpire)
+	if _, err := pipe.Exec(); err != nil {
+		return nil, fmt.Errorf("保存 Token 失败: %w", err)
+	}
+
+	return tokenPair, nil
+}
+
+// IssueImpersonationToken 签发模拟登录的 Access Token，令牌族记录真实操作人，到期自动失效
+// 模拟登录会话出现在被模拟用户的登录设备列表中，可以像普通会话一样注销
+func (service *TokenService) IssueImpersonationToken(userID uint, username string, actorID uint, actorUsername, clientIP, userAgent string, expire time.Duration) (string, string, error) {
+	familyID := auth.NewTokenID()
+	accessToken, accessTokenID, err := auth.GenerateImpersonationToken(userID, username, actorID, actorUsername, familyID, expire)
+	if err != nil {
+		return "", "", err
+	}
+
+	familyKey := fmt.Sprintf(tokenFamilyKey, familyID)
+	userKey := fmt.Sprintf(tokenUserKey, userID)
+	actorKey := fmt.Sprintf(tokenActorKey, actorID)
+
+	pipe := db.DB.Redis.TxPipeline()
+	pipe.HMSet(familyKey, map[string]interface{}{
+		"user_id":        userID,
+		"access_jti":     accessTokenID,
+		"actor_id":       actorID,
+		"actor_username": actorUsername,
+		"client_ip":      clientIP,
+		"user_agent":     userAgent,
+		"issued_at":      time.Now().Unix(),
+		"last_seen":      time.Now().Unix(),
+	})
+	pipe.Expire(familyKey, expire)
+	pipe.SAdd(userKey, familyID)
+	pipe.Expire(userKey, auth.RefreshTokenExpire)
+	pipe.SAdd(actorKey, familyID)
+	pipe.Expire(actorKey, auth.RefreshTokenExpire)
+	if _, err := pipe.Exec(); err != nil {
+		return "", "", fmt.Errorf("保存 Token 失败: %w", err)
+	}
+
+	return accessToken, familyID, nil
+}
+
+// RefreshTokenPair 使用 Refresh Token 换取新的 Token 对（Refresh Token 轮换）
+func (service *TokenService) RefreshTokenPair(refreshToken string) (*auth.TokenPair, error) {
+	// 解析 Refresh Token
+	claims, err := auth.ParseToken(refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Refresh Token: %w", err)
+	}
+
+	// 验证是否为 Refresh Token
+	if claims.TokenType != "refresh" {
+		return nil, errors.New("Token 类型错误，需要 Refresh Token")
+	}
+
+	// 生成新的 Token 对，沿用原令牌族
+	tokenPair, err := auth.GenerateTokenPair(claims.UserID, claims.Username, claims.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{fmt.Sprintf(tokenFamilyKey, claims.FamilyID), fmt.Sprintf(tokenUserKey, claims.UserID)}
+	if claims.ActorID != 0 {
+		keys = append(keys, fmt.Sprintf(tokenActorKey, claims.ActorID))
+	}
+	result, err := rotateRefreshScript.Run(db.DB.Redis, keys,
+		claims.ID, tokenPair.AccessTokenID, tokenPair.RefreshTokenID, int64(auth.RefreshTokenExpire.Seconds()), time.Now().Unix(), claims.FamilyID).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("刷新 Token 失败: %w", err)
+	}
+
+	switch result {
+	case -1:
+		return nil, errors.New("登录已失效")
+	case 0:
+		// Refresh Token 被重复使用，说明可能已泄露，注销整个令牌族
+		if err := service.RevokeFamily(claims.UserID, claims.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("Refresh Token 已被使用，登录会话已注销")
+	}
+
+	return tokenPair, nil
+}
+
+// ValidateAccessToken 校验 Access Token 是否仍然有效（未注销、未被轮换），并刷新会话最后活跃时间
+func (service *TokenService) ValidateAccessToken(claims *auth.Claims, clientIP string) error {
+	familyKey := fmt.Sprintf(tokenFamilyKey, claims.FamilyID)
+	result, err := validateAccessScript.Run(db.DB.Redis, []string{familyKey}, claims.ID, time.Now().Unix(), clientIP).Int64()
+	if err != nil {
+		return fmt.Errorf("查询 Token 失败: %w", err)
+	}
+
+	switch result {
+	case -1:
+		return errors.New("登录已失效")
+	case 0:
+		return errors.New("Token 已失效")
+	}
+
+	return nil
+}
+
+// RevokeFamily 注销令牌族（登出）
+func (service *TokenService) RevokeFamily(userID uint, familyID string) error {
+	pipe := db.DB.Redis.TxPipeline()
+	pipe.Del(fmt.Sprintf(tokenFamilyKey, familyID))
+	pipe.SRem(fmt.Sprintf(tokenUserKey, userID), familyID)
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("注销 Token 失败: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens 注销用户的所有令牌族，以及该用户发起的模拟登录（禁用用户、删除用户时调用）
+func (service *TokenService) RevokeUserTokens(userID uint) error {
+	userKey := fmt.Sprintf(tokenUserKey, userID)
+	actorKey := fmt.Sprintf(tokenActorKey, userID)
+
+	familyIDs, err := db.DB.Redis.SUnion(userKey, actorKey).Result()
+	if err != nil {
+		return fmt.Errorf("查询用户 Token 失败: %w", err)
+	}
+
+	keys := make([]string, 0, len(familyIDs)+2)
+	for _, familyID := range familyIDs {
+		keys = append(keys, fmt.Sprintf(tokenFamilyKey, familyID))
+	}
+	keys = append(keys, userKey, actorKey)
+
+	if err := db.DB.Redis.Del(keys...).Err(); err != nil {
+		return fmt.Errorf("注销用户 Token 失败: %w", err)
+	}
+
+	return nil
+}
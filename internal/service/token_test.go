@@ -0,0 +1,40 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/auth"
+	"testing"
+	"time"
+)
+
+// TestRefreshKeepsSessionRevocable 一直刷新而不重新登录的会话，超过首次登录时用户令牌族集合的有效期后仍能被注销
+func TestRefreshKeepsSessionRevocable(t *testing.T) {
+	server := newMockRedis(t)
+	useHS256(t)
+	var tokenService service.TokenService
+
+	tokenPair, err := tokenService.IssueTokenPair(1, "admin", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 在 Refresh Token 过期前刷新，再越过首次登录时的有效期
+	server.FastForward(auth.RefreshTokenExpire - time.Hour)
+	tokenPair, err = tokenService.RefreshTokenPair(tokenPair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(2 * time.Hour)
+
+	sessions, err := server.Members("token:user:1")
+	if err != nil || len(sessions) != 1 || sessions[0] != tokenPair.FamilyID {
+		t.Fatalf("用户令牌族集合 = %v, %v，期望包含 %s", sessions, err, tokenPair.FamilyID)
+	}
+
+	if err := tokenService.RevokeUserTokens(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokenService.RefreshTokenPair(tokenPair.RefreshToken); err == nil {
+		t.Error("注销用户的所有会话后 Refresh Token 应失效")
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/auth"
+	"ffly-baisc/pkg/totp"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+// TwoFactorService 两步验证（TOTP）服务
+type TwoFactorService struct{}
+
+// ErrTwoFactorCodeInvalid 两步验证码错误，登录时需要计入失败次数
+var ErrTwoFactorCodeInvalid = errors.New("验证码错误")
+
+const (
+	twoFactorSetupKey     = "2fa:setup:%d"     // 待确认绑定的 TOTP 密钥
+	twoFactorLastStepKey  = "2fa:last_step:%d" // 最近一次使用的时间步，防止验证码重放
+	twoFactorChallengeKey = "2fa:challenge:%s" // 挑战 Token 的验证次数
+
+	twoFactorSetupExpire     = 10 * time.Minute // 绑定有效期
+	twoFactorSkew            = 1                // 允许前后一个时间步的误差
+	twoFactorMaxAttempts     = 5                // 每个挑战 Token 最多验证次数
+	twoFactorRecoveryCodeNum = 10               // 恢复码数量
+)
+
+// Setup 生成 TOTP 密钥，用户在身份验证器中添加后调用 Enable 确认开启
+func (service *TwoFactorService) Setup(userID uint) (*model.TwoFactorSetup, error) {
+	var user model.User
+	if err := db.DB.MySQL.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.New("已开启两步验证")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Redis.Set(fmt.Sprintf(twoFactorSetupKey, userID), secret, twoFactorSetupExpire).Err(); err != nil {
+		return nil, fmt.Errorf("保存两步验证密钥失败: %w", err)
+	}
+
+	return &model.TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(config.GlobalConfig.App.Name, *user.Username, secret),
+	}, nil
+}
+
+// Enable 校验验证码并开启两步验证，返回恢复码（仅返回这一次）
+func (service *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	setupKey := fmt.Sprintf(twoFactorSetupKey, userID)
+	secret, err := db.DB.Redis.Get(setupKey).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("请先获取两步验证密钥")
+		}
+		return nil, fmt.Errorf("查询两步验证密钥失败: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), twoFactorSkew)
+	if !ok {
+		return nil, ErrTwoFactorCodeInvalid
+	}
+
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"two_factor_enabled": true,
+		"two_factor_secret":  secret,
+	}).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("开启两步验证失败: %w", err)
+	}
+
+	recoveryCodes, err := service.saveRecoveryCodes(tx, userID)
+	if err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, err
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	db.DB.Redis.Del(setupKey)
+	db.DB.Redis.Set(fmt.Sprintf(twoFactorLastStepKey, userID), step, (2*twoFactorSkew+1)*totp.Period*time.Second)
+
+	return recoveryCodes, nil
+}
+
+// Disable 校验验证码并关闭两步验证，角色要求两步验证时不允许关闭
+func (service *TwoFactorService) Disable(userID uint, code string) error {
+	var user model.User
+	if err := db.DB.MySQL.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if !user.TwoFactorEnabled {
+		return errors.New("未开启两步验证")
+	}
+
+	required, err := service.IsRequired(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("当前角色要求开启两步验证，不允许关闭")
+	}
+
+	ok, err := service.VerifyCode(&user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("验证码错误")
+	}
+
+	return service.Reset(userID)
+}
+
+// Reset 重置两步验证（管理员操作，用于用户丢失身份验证器的情况）
+func (service *TwoFactorService) Reset(userID uint) error {
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"two_factor_enabled": false,
+		"two_factor_secret":  nil,
+	}).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return fmt.Errorf("关闭两步验证失败: %w", err)
+	}
+
+	if err := tx.Where("user_id = ?", userID).Unscoped().Delete(&model.UserRecoveryCode{}).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return fmt.Errorf("删除恢复码失败: %w", err)
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧恢复码全部失效
+func (service *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var user model.User
+	if err := db.DB.MySQL.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("未开启两步验证")
+	}
+
+	ok, err := service.VerifyCode(&user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("验证码错误")
+	}
+
+	return service.saveRecoveryCodes(db.DB.MySQL, userID)
+}
+
+// VerifyCode 校验 TOTP 验证码或恢复码，恢复码使用后失效
+func (service *TwoFactorService) VerifyCode(user *model.User, code string) (bool, error) {
+	if user.TwoFactorSecret == nil {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+
+	// TOTP 验证码
+	if step, ok := totp.Validate(*user.TwoFactorSecret, code, time.Now(), twoFactorSkew); ok {
+		// 同一时间步的验证码只能使用一次
+		lastStepKey := fmt.Sprintf(twoFactorLastStepKey, user.ID)
+		lastStep, err := db.DB.Redis.Get(lastStepKey).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return false, fmt.Errorf("查询验证码使用记录失败: %w", err)
+		}
+		if step <= lastStep {
+			return false, nil
+		}
+
+		db.DB.Redis.Set(lastStepKey, step, (2*twoFactorSkew+1)*totp.Period*time.Second)
+		return true, nil
+	}
+
+	// 恢复码
+	result := db.DB.MySQL.Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("校验恢复码失败: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// IsRequired 用户的角色是否要求开启两步验证
+func (service *TwoFactorService) IsRequired(userID uint) (bool, error) {
+	var count int64
+	if err := db.DB.MySQL.Model(&model.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id AND user_roles.deleted_at IS NULL").
+		Where("user_roles.user_id = ? AND roles.require_two_factor = ? AND roles.status = 1", userID, true).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// IssueChallenge 密码校验通过后签发两步验证挑战 Token
+func (service *TwoFactorService) IssueChallenge(user *model.User) (string, error) {
+	challengeToken, tokenID, err := auth.GenerateChallengeToken(user.ID, *user.Username)
+	if err != nil {
+		return "", err
+	}
+
+	if err := db.DB.Redis.Set(fmt.Sprintf(twoFactorChallengeKey, tokenID), 0, auth.ChallengeTokenExpire).Err(); err != nil {
+		return "", fmt.Errorf("保存挑战 Token 失败: %w", err)
+	}
+
+	return challengeToken, nil
+}
+
+// VerifyChallenge 校验挑战 Token，并累计验证次数
+func (service *TwoFactorService) VerifyChallenge(challengeToken string) (*auth.Claims, error) {
+	claims, err := auth.ParseToken(challengeToken)
+	if err != nil {
+		return nil, fmt.Errorf("无效的挑战 Token: %w", err)
+	}
+
+	if claims.TokenType != "2fa_challenge" {
+		return nil, errors.New("Token 类型错误，需要挑战 Token")
+	}
+
+	challengeKey := fmt.Sprintf(twoFactorChallengeKey, claims.ID)
+	exists, err := db.DB.Redis.Exists(challengeKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询挑战 Token 失败: %w", err)
+	}
+	if exists == 0 {
+		return nil, errors.New("挑战 Token 已失效，请重新登录")
+	}
+
+	attempts, err := db.DB.Redis.Incr(challengeKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询挑战 Token 失败: %w", err)
+	}
+	if attempts > twoFactorMaxAttempts {
+		db.DB.Redis.Del(challengeKey)
+		return nil, errors.New("验证次数过多，请重新登录")
+	}
+
+	return claims, nil
+}
+
+// ConsumeChallenge 验证通过后作废挑战 Token
+func (service *TwoFactorService) ConsumeChallenge(claims *auth.Claims) {
+	db.DB.Redis.Del(fmt.Sprintf(twoFactorChallengeKey, claims.ID))
+}
+
+// saveRecoveryCodes 生成并保存恢复码，返回明文恢复码
+func (service *TwoFactorService) saveRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	// 删除旧的恢复码 需要硬删除
+	if err := tx.Where("user_id = ?", userID).Unscoped().Delete(&model.UserRecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("删除恢复码失败: %w", err)
+	}
+
+	codes := make([]string, 0, twoFactorRecoveryCodeNum)
+	recoveryCodes := make([]model.UserRecoveryCode, 0, twoFactorRecoveryCodeNum)
+	for i := 0; i < twoFactorRecoveryCodeNum; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, model.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+
+	if err := tx.Create(&recoveryCodes).Error; err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，恢复码为高熵随机值，使用 SHA-256 即可
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,8 +5,11 @@ import (
 	"ffly-baisc/internal/db"
 	"ffly-baisc/internal/model"
 	"ffly-baisc/pkg/query"
+	types "ffly-baisc/pkg/type"
 	"ffly-baisc/pkg/utils"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,41 +19,16 @@ type UserService struct{}
 
 // GetUserList 获取用户列表
 func (service *UserService) GetUserList(c *gin.Context) ([]*model.User, *query.Pagination, error) {
-	// 开启事务
-	tx := db.DB.MySQL.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback() // 回滚事务
-		}
-	}()
-
 	users, pagination, err := query.GetQueryData[model.User](db.DB.MySQL, c)
 	if err != nil {
-		tx.Rollback() // 回滚事务
 		return nil, nil, err
 	}
 
-	// 为每个用户填充角色信息
-	var userRoleService UserRoleService
-	for _, user := range *users {
-		userRoles, err := userRoleService.GetRolesByUserID(db.DB.MySQL, user.ID)
-		if err != nil {
-			tx.Rollback() // 回滚事务
-			return nil, nil, fmt.Errorf("获取用户角色失败: %w", err)
-		}
-		// 获取 roleIds
-		var roleIds []uint
-		for _, userRole := range userRoles {
-			roleIds = append(roleIds, userRole.RoleID)
-		}
-		// 获取角色信息
-		var roles []*model.Role
-		if err := db.DB.MySQL.Model(&model.Role{}).Where("id in (?)", roleIds).Find(&roles).Error; err != nil {
-			tx.Rollback() // 回滚事务
-			return nil, nil, fmt.Errorf("获取角色信息失败: %w", err)
+	// 指定 expand=roles 时填充角色信息
+	if query.IsExpanded(c, "roles") {
+		if err := service.fillRoles(*users...); err != nil {
+			return nil, nil, err
 		}
-		// 填充角色信息
-		user.Roles = roles
 	}
 
 	return *users, pagination, nil
@@ -58,45 +36,43 @@ func (service *UserService) GetUserList(c *gin.Context) ([]*model.User, *query.P
 
 // GetUserByID 根据 ID 获取用户信息
 func (service *UserService) GetUserByID(id uint) (*model.User, error) {
-	// 开启事务
-	tx := db.DB.MySQL.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback() // 回滚事务
-		}
-	}()
-
 	user := &model.User{}
 	if err := db.DB.MySQL.First(user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			tx.Rollback() // 回滚事务
 			return nil, fmt.Errorf("用户不存在")
 		}
 		return nil, err
 	}
 
 	// 用户填充角色信息
+	if err := service.fillRoles(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// fillRoles 批量填充用户的角色信息，查询次数与用户数量无关
+func (service *UserService) fillRoles(users ...*model.User) error {
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+
 	var userRoleService UserRoleService
-	userRoles, err := userRoleService.GetRolesByUserID(db.DB.MySQL, user.ID)
+	roles, err := userRoleService.GetRolesByUserIDs(db.DB.MySQL, userIDs)
 	if err != nil {
-		tx.Rollback() // 回滚事务
-		return nil, fmt.Errorf("获取用户角色失败: %w", err)
-	}
-	// 获取 roleIds
-	var roleIds []uint
-	for _, userRole := range userRoles {
-		roleIds = append(roleIds, userRole.RoleID)
+		return fmt.Errorf("获取用户角色失败: %w", err)
 	}
-	// 获取角色信息
-	var roles []*model.Role
-	if err := db.DB.MySQL.Model(&model.Role{}).Where("id in (?)", roleIds).Find(&roles).Error; err != nil {
-		tx.Rollback() // 回滚事务
-		return nil, fmt.Errorf("获取角色信息失败: %w", err)
+
+	for _, user := range users {
+		user.Roles = roles[user.ID]
+		if user.Roles == nil {
+			user.Roles = []*model.Role{}
+		}
 	}
-	// 填充角色信息
-	user.Roles = roles
 
-	return user, nil
+	return nil
 }
 
 // CreateUser 创建用户
@@ -115,11 +91,31 @@ func (service *UserService) CreateUser(userCreateRequest *model.UserCreateReques
 		return fmt.Errorf("手机号不合规")
 	}
 
+	// 校验用户名格式
+	if userCreateRequest.Username != nil {
+		if err := validateUsername(*userCreateRequest.Username); err != nil {
+			tx.Rollback() // 回滚事务
+			return err
+		}
+	}
+
 	// 加密密码
 	if userCreateRequest.Password == nil {
 		tx.Rollback() // 回滚事务
 		return fmt.Errorf("密码不能为空")
 	}
+
+	// 校验密码策略
+	var passwordPolicyService PasswordPolicyService
+	var username string
+	if userCreateRequest.Username != nil {
+		username = *userCreateRequest.Username
+	}
+	if err := passwordPolicyService.Validate(0, username, *userCreateRequest.Password); err != nil {
+		tx.Rollback() // 回滚事务
+		return err
+	}
+
 	hashedPassword, err := utils.EncodePassword(*userCreateRequest.Password)
 	if err != nil {
 		tx.Rollback() // 回滚事务
@@ -128,14 +124,17 @@ func (service *UserService) CreateUser(userCreateRequest *model.UserCreateReques
 	userCreateRequest.Password = &hashedPassword
 
 	// 将请求数据转换为User模型
+	passwordChangedAt := time.Now()
 	user := &model.User{
-		Username:  userCreateRequest.Username,
-		Password:  userCreateRequest.Password,
-		Nickname:  userCreateRequest.Nickname,
-		Email:     userCreateRequest.Email,
-		Phone:     userCreateRequest.Phone,
-		Status:    userCreateRequest.Status,
-		BaseModel: userCreateRequest.BaseModel,
+		Username:          userCreateRequest.Username,
+		Password:          userCreateRequest.Password,
+		Nickname:          userCreateRequest.Nickname,
+		Email:             userCreateRequest.Email,
+		Phone:             userCreateRequest.Phone,
+		Status:            userCreateRequest.Status,
+		AuthSource:        userCreateRequest.AuthSource,
+		PasswordChangedAt: &passwordChangedAt,
+		BaseModel:         userCreateRequest.BaseModel,
 	}
 
 	// 创建用户
@@ -144,6 +143,12 @@ func (service *UserService) CreateUser(userCreateRequest *model.UserCreateReques
 		return err
 	}
 
+	// 记录历史密码
+	if err := passwordPolicyService.RecordHistory(tx, user.ID, hashedPassword); err != nil {
+		tx.Rollback() // 回滚事务
+		return err
+	}
+
 	// 设置创建后的ID
 	userCreateRequest.ID = user.ID
 
@@ -195,6 +200,12 @@ func (service *UserService) DeleteUser(id uint) error {
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
 
+	// 注销用户的所有登录会话
+	var tokenService TokenService
+	if err := tokenService.RevokeUserTokens(id); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -214,6 +225,24 @@ func (service *UserService) PatchUser(id uint, userPatchRequest *model.UserPatch
 		return fmt.Errorf("手机号不合规")
 	}
 
+	// 校验用户名格式
+	if userPatchRequest.Username != nil {
+		if err := validateUsername(*userPatchRequest.Username); err != nil {
+			tx.Rollback() // 回滚事务
+			return err
+		}
+	}
+
+	// 查询原用户信息，用于判断邮箱/手机号是否变更
+	var oldUser model.User
+	if err := tx.First(&oldUser, id).Error; err != nil {
+		tx.Rollback() // 回滚事务
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("用户不存在")
+		}
+		return err
+	}
+
 	// 更新用户角色关联，
 	if len(userPatchRequest.RoleIDs) > 0 {
 		// 多个的话，
@@ -231,12 +260,50 @@ func (service *UserService) PatchUser(id uint, userPatchRequest *model.UserPatch
 		return result.Error
 	}
 
+	// 邮箱/手机号变更后需要重新验证
+	emailChanged := userPatchRequest.Email != nil && (oldUser.Email == nil || !strings.EqualFold(*oldUser.Email, *userPatchRequest.Email))
+	phoneChanged := userPatchRequest.Phone != nil && (oldUser.Phone == nil || *oldUser.Phone != *userPatchRequest.Phone)
+	verifiedUpdates := map[string]any{}
+	if emailChanged {
+		verifiedUpdates["email_verified"] = false
+	}
+	if phoneChanged {
+		verifiedUpdates["phone_verified"] = false
+	}
+	if len(verifiedUpdates) > 0 {
+		if err := tx.Model(&model.User{}).Where("id = ?", id).Updates(verifiedUpdates).Error; err != nil {
+			tx.Rollback() // 回滚事务
+			return err
+		}
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback() // 回滚事务
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
 
+	// 禁用用户时注销其所有登录会话
+	if userPatchRequest.Status == types.StatusDisabled {
+		var tokenService TokenService
+		if err := tokenService.RevokeUserTokens(id); err != nil {
+			return err
+		}
+	}
+
+	// 发送新邮箱/手机号的验证码
+	var verificationService VerificationService
+	if emailChanged && *userPatchRequest.Email != "" {
+		if err := verificationService.SendCode(id, VerifyTypeEmail, *userPatchRequest.Email); err != nil {
+			return fmt.Errorf("更新成功，但验证码发送失败: %w", err)
+		}
+	}
+	if phoneChanged && *userPatchRequest.Phone != "" {
+		if err := verificationService.SendCode(id, VerifyTypePhone, *userPatchRequest.Phone); err != nil {
+			return fmt.Errorf("更新成功，但验证码发送失败: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -247,8 +314,16 @@ func (service *UserService) UpdatePassword(id uint, updatePasswordRequest *model
 	if err != nil {
 		return err
 	}
+	if AuthSourceOf(user) != AuthSourceLocal {
+		return errDirectoryPassword
+	}
+	// 校验新密码是否为空
+	if updatePasswordRequest.Password == nil || updatePasswordRequest.NewPassword == nil {
+		return fmt.Errorf("旧密码和新密码不能为空")
+	}
+
 	// 校验密码与确认密码是否一致
-	if updatePasswordRequest.NewPassword != nil && updatePasswordRequest.PasswordConfirm != nil &&
+	if updatePasswordRequest.PasswordConfirm != nil &&
 		*updatePasswordRequest.NewPassword != *updatePasswordRequest.PasswordConfirm {
 		return fmt.Errorf("新密码和确认密码不匹配")
 	}
@@ -258,16 +333,45 @@ func (service *UserService) UpdatePassword(id uint, updatePasswordRequest *model
 		return fmt.Errorf("旧密码错误")
 	}
 
+	// 校验密码策略
+	var passwordPolicyService PasswordPolicyService
+	if err := passwordPolicyService.Validate(id, *user.Username, *updatePasswordRequest.NewPassword); err != nil {
+		return err
+	}
+
 	// 加密密码
 	hashedPassword, err := utils.EncodePassword(*updatePasswordRequest.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	// 更新密码
-	if err := db.DB.MySQL.Model(&model.User{}).Where("id = ?", id).Update("password", &hashedPassword).Error; err != nil {
+	// 开启事务
+	tx := db.DB.MySQL.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // 回滚事务
+		}
+	}()
+
+	// 更新密码并记录历史密码
+	if err := passwordPolicyService.SavePassword(tx, id, hashedPassword); err != nil {
+		tx.Rollback() // 回滚事务
 		return err
 	}
 
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // 回滚事务
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+
+	return nil
+}
+
+// validateUsername 用户名不能是邮箱或手机号格式，登录时这两种格式按邮箱和手机号匹配
+func validateUsername(username string) error {
+	if strings.Contains(username, "@") || utils.IsPhone(strings.TrimSpace(username)) {
+		return errors.New("用户名不能包含 @ 或使用手机号格式")
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/query"
 	types "ffly-baisc/pkg/type"
 	"fmt"
 
@@ -109,3 +110,13 @@ func (service *UserRoleService) GetRolesByUserID(tx *gorm.DB, userID uint) ([]*m
 
 	return userRoles, nil
 }
+
+// GetRolesByUserIDs 批量获取多个用户的角色信息，共两次查询，返回 用户ID -> 角色列表
+func (service *UserRoleService) GetRolesByUserIDs(tx *gorm.DB, userIDs []uint) (map[uint][]*model.Role, error) {
+	roles, err := query.LoadJoin(tx, &model.UserRole{}, "user_id", "role_id", userIDs, func(role *model.Role) uint { return role.ID })
+	if err != nil {
+		return nil, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+
+	return roles, nil
+}
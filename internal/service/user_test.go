@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/service"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// userListRoleNum 模拟数据中的角色数量，每个用户分配其中两个角色
+const userListRoleNum = 5
+
+// expectUserListWithRoles 预期用户列表 expand=roles 的全部查询：总数、用户、用户角色关联、角色
+func expectUserListWithRoles(mock sqlmock.Sqlmock, size int) {
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(size))
+
+	users := sqlmock.NewRows([]string{"id", "username"})
+	for i := 1; i <= size; i++ {
+		users.AddRow(i, fmt.Sprintf("user%d", i))
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `users`").WillReturnRows(users)
+
+	userRoles := sqlmock.NewRows([]string{"owner_id", "target_id"})
+	for i := 1; i <= size; i++ {
+		userRoles.AddRow(i, i%userListRoleNum+1)
+		userRoles.AddRow(i, (i+1)%userListRoleNum+1)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `user_roles`").WillReturnRows(userRoles)
+
+	roles := sqlmock.NewRows([]string{"id", "name", "code"})
+	for i := 1; i <= userListRoleNum; i++ {
+		roles.AddRow(i, fmt.Sprintf("角色%d", i), fmt.Sprintf("role%d", i))
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `roles`").WillReturnRows(roles)
+}
+
+func newUserListContext(size int) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", fmt.Sprintf("/user?page=1&size=%d&expand=roles", size), nil)
+
+	return c
+}
+
+// TestGetUserListExpandRolesQueryCount 展开角色时查询次数固定，与每页数量无关
+func TestGetUserListExpandRolesQueryCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, size := range []int{10, 100, 1000} {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			mock, count := newMockMySQL(t)
+			expectUserListWithRoles(mock, size)
+
+			var userService service.UserService
+			users, _, err := userService.GetUserList(newUserListContext(size))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+
+			if *count != 4 {
+				t.Errorf("查询次数 = %d，期望 4", *count)
+			}
+			if len(users) != size {
+				t.Fatalf("用户数量 = %d，期望 %d", len(users), size)
+			}
+			for _, user := range users {
+				if len(user.Roles) != 2 {
+					t.Fatalf("用户 %d 的角色数量 = %d，期望 2", user.ID, len(user.Roles))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetUserListExpandRoles(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			mock, count := newMockMySQL(b)
+			var userService service.UserService
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectUserListWithRoles(mock, size)
+				c := newUserListContext(size)
+				b.StartTimer()
+
+				if _, _, err := userService.GetUserList(c); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(*count)/float64(b.N), "queries/op")
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/db"
+	"ffly-baisc/internal/model"
+	"ffly-baisc/pkg/mail"
+	"ffly-baisc/pkg/sms"
+	types "ffly-baisc/pkg/type"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	VerifyTypeEmail = "email" // 邮箱验证
+	VerifyTypePhone = "phone" // 手机号验证
+	VerifyTypeLogin = "login" // 短信验证码登录
+
+	verifyCodeKey     = "verify:code:%s:%s"     // 验证码
+	verifyAttemptsKey = "verify:attempts:%s:%s" // 验证码校验次数
+	verifyResendKey   = "verify:resend:%s:%s"   // 重发间隔限制
+	verifyDailyKey    = "verify:daily:%s:%s"    // 每日发送次数
+
+	verifyMaxAttempts = 5 // 每个验证码最多校验次数
+)
+
+// VerificationService 邮箱/手机号验证服务
+type VerificationService struct{}
+
+// SendCode 向用户的邮箱或手机号发送验证码
+func (service *VerificationService) SendCode(userID uint, verifyType, target string) error {
+	target = normalizeVerifyTarget(verifyType, target)
+	verifyConfig := config.GlobalConfig.Verify
+
+	// 重发间隔限制
+	resendInterval := time.Duration(verifyConfig.ResendInterval) * time.Second
+	if resendInterval <= 0 {
+		resendInterval = time.Minute
+	}
+	ok, err := db.DB.Redis.SetNX(fmt.Sprintf(verifyResendKey, verifyType, target), 1, resendInterval).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("发送过于频繁，请稍后再试")
+	}
+
+	// 每日发送次数限制
+	dailyKey := fmt.Sprintf(verifyDailyKey, verifyType, target)
+	count, err := db.DB.Redis.Incr(dailyKey).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		db.DB.Redis.Expire(dailyKey, 24*time.Hour)
+	}
+	if verifyConfig.MaxDailySends > 0 && count > int64(verifyConfig.MaxDailySends) {
+		return errors.New("今日发送次数已达上限")
+	}
+
+	code, err := generateVerifyCode()
+	if err != nil {
+		return err
+	}
+
+	codeExpire := verifyCodeExpire()
+	codeKey := fmt.Sprintf(verifyCodeKey, verifyType, target)
+	pipe := db.DB.Redis.TxPipeline()
+	pipe.HMSet(codeKey, map[string]interface{}{
+		"code":    code,
+		"user_id": userID,
+	})
+	pipe.Expire(codeKey, codeExpire)
+	pipe.Del(fmt.Sprintf(verifyAttemptsKey, verifyType, target))
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("保存验证码失败: %w", err)
+	}
+
+	content := fmt.Sprintf("您的验证码为 %s，%d 分钟内有效。如非本人操作，请忽略。", code, int(codeExpire.Minutes()))
+	switch verifyType {
+	case VerifyTypeEmail:
+		return mail.NewMailer().Send(&mail.Message{
+			To:      []string{target},
+			Subject: fmt.Sprintf("[%s] 邮箱验证", config.GlobalConfig.App.Name),
+			Body:    content,
+		})
+	case VerifyTypePhone:
+		return sms.NewSender().Send(target, content)
+	case VerifyTypeLogin:
+		content = fmt.Sprintf("您的登录验证码为 %s，%d 分钟内有效。如非本人操作，请忽略。", code, int(codeExpire.Minutes()))
+		return sms.NewSender().Send(target, content)
+	}
+
+	return errors.New("不支持的验证类型")
+}
+
+// SendUserCodes 向用户尚未验证的邮箱和手机号发送验证码
+func (service *VerificationService) SendUserCodes(user *model.User) error {
+	if user.Email != nil && *user.Email != "" && !user.EmailVerified {
+		if err := service.SendCode(user.ID, VerifyTypeEmail, *user.Email); err != nil {
+			return err
+		}
+	}
+
+	if user.Phone != nil && *user.Phone != "" && !user.PhoneVerified {
+		if err := service.SendCode(user.ID, VerifyTypePhone, *user.Phone); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Confirm 校验验证码，标记邮箱/手机号已验证，待验证用户完成所有验证后自动启用
+func (service *VerificationService) Confirm(verifyType, target, code string) error {
+	target = normalizeVerifyTarget(verifyType, target)
+	userID, err := service.CheckCode(verifyType, target, code)
+	if err != nil {
+		return err
+	}
+
+	var user model.User
+	if err := db.DB.MySQL.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("用户不存在")
+		}
+		return err
+	}
+
+	// 验证码发送后用户可能已修改邮箱/手机号
+	updates := map[string]any{}
+	switch verifyType {
+	case VerifyTypeEmail:
+		if user.Email == nil || normalizeVerifyTarget(verifyType, *user.Email) != target {
+			return errors.New("邮箱已变更，请重新获取验证码")
+		}
+		user.EmailVerified = true
+		updates["email_verified"] = true
+	case VerifyTypePhone:
+		if user.Phone == nil || *user.Phone != target {
+			return errors.New("手机号已变更，请重新获取验证码")
+		}
+		user.PhoneVerified = true
+		updates["phone_verified"] = true
+	default:
+		return errors.New("不支持的验证类型")
+	}
+
+	// 待验证用户完成所有验证后启用
+	if user.Status == types.StatusPending && isUserVerified(&user) {
+		updates["status"] = types.StatusEnabled
+	}
+
+	if err := db.DB.MySQL.Model(&model.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新验证状态失败: %w", err)
+	}
+
+	return nil
+}
+
+// CheckCode 校验验证码，校验通过后验证码失效，返回发送验证码时记录的用户ID
+func (service *VerificationService) CheckCode(verifyType, target, code string) (uint, error) {
+	target = normalizeVerifyTarget(verifyType, target)
+	codeKey := fmt.Sprintf(verifyCodeKey, verifyType, target)
+	attemptsKey := fmt.Sprintf(verifyAttemptsKey, verifyType, target)
+
+	values, err := db.DB.Redis.HGetAll(codeKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, errors.New("验证码无效或已过期")
+	}
+
+	// 校验次数限制
+	attempts, err := db.DB.Redis.Incr(attemptsKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		db.DB.Redis.Expire(attemptsKey, verifyCodeExpire())
+	}
+	if attempts > verifyMaxAttempts {
+		db.DB.Redis.Del(codeKey, attemptsKey)
+		return 0, errors.New("验证次数过多，请重新获取验证码")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(values["code"]), []byte(strings.TrimSpace(code))) != 1 {
+		return 0, errors.New("验证码错误")
+	}
+	db.DB.Redis.Del(codeKey, attemptsKey)
+
+	userID, err := strconv.ParseUint(values["user_id"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("验证码数据错误: %w", err)
+	}
+
+	return uint(userID), nil
+}
+
+// ResendCode 重新发送验证码，目标不存在或已验证时静默返回，避免泄露用户信息
+func (service *VerificationService) ResendCode(verifyType, target string) error {
+	target = normalizeVerifyTarget(verifyType, target)
+
+	var user model.User
+	var err error
+	switch verifyType {
+	case VerifyTypeEmail:
+		err = db.DB.MySQL.Where("email = ? AND email_verified = ?", target, false).First(&user).Error
+	case VerifyTypePhone:
+		err = db.DB.MySQL.Where("phone = ? AND phone_verified = ?", target, false).First(&user).Error
+	default:
+		return errors.New("不支持的验证类型")
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return service.SendCode(user.ID, verifyType, target)
+}
+
+// isUserVerified 用户填写的邮箱和手机号是否都已验证
+func isUserVerified(user *model.User) bool {
+	if user.Email != nil && *user.Email != "" && !user.EmailVerified {
+		return false
+	}
+	if user.Phone != nil && *user.Phone != "" && !user.PhoneVerified {
+		return false
+	}
+	return true
+}
+
+// normalizeVerifyTarget 规范化验证目标，邮箱不区分大小写
+func normalizeVerifyTarget(verifyType, target string) string {
+	target = strings.TrimSpace(target)
+	if verifyType == VerifyTypeEmail {
+		return strings.ToLower(target)
+	}
+	return target
+}
+
+// verifyCodeExpire 验证码有效期
+func verifyCodeExpire() time.Duration {
+	if config.GlobalConfig.Verify.CodeExpire <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(config.GlobalConfig.Verify.CodeExpire) * time.Second
+}
+
+// generateVerifyCode 生成 6 位数字验证码
+func generateVerifyCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("生成验证码失败: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// SendVerifyCodeService 重新发送验证码请求
+type SendVerifyCodeService struct {
+	Type   string `json:"type" binding:"required,oneof=email phone"`
+	Target string `json:"target" binding:"required"` // 邮箱或手机号
+}
+
+// Send 重新发送验证码
+func (service *SendVerifyCodeService) Send() error {
+	var verificationService VerificationService
+	return verificationService.ResendCode(service.Type, service.Target)
+}
+
+// ConfirmVerifyCodeService 校验验证码请求
+type ConfirmVerifyCodeService struct {
+	Type   string `json:"type" binding:"required,oneof=email phone"`
+	Target string `json:"target" binding:"required"` // 邮箱或手机号
+	Code   string `json:"code" binding:"required"`
+}
+
+// Confirm 校验验证码
+func (service *ConfirmVerifyCodeService) Confirm() error {
+	var verificationService VerificationService
+	return verificationService.Confirm(service.Type, service.Target, service.Code)
+}
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"ffly-baisc/internal/config"
+	"ffly-baisc/internal/service"
+	"ffly-baisc/pkg/sms"
+	"regexp"
+	"testing"
+)
+
+// useFakeSMS 短信使用 fake 驱动，不限制每日发送次数
+func useFakeSMS(t *testing.T) {
+	t.Helper()
+
+	smsConfig := config.GlobalConfig.SMS
+	verifyConfig := config.GlobalConfig.Verify
+	t.Cleanup(func() {
+		config.GlobalConfig.SMS = smsConfig
+		config.GlobalConfig.Verify = verifyConfig
+		sms.DefaultFakeSender.Reset()
+	})
+	config.GlobalConfig.SMS.Driver = "fake"
+	config.GlobalConfig.Verify.MaxDailySends = 0
+	sms.DefaultFakeSender.Reset()
+}
+
+var verifyCodePattern = regexp.MustCompile(`\d{6}`)
+
+// sendLoginCode 发送登录验证码，从 fake 短信中读取验证码
+func sendLoginCode(t *testing.T, userID uint, phone string) string {
+	t.Helper()
+
+	var verificationService service.VerificationService
+	if err := verificationService.SendCode(userID, service.VerifyTypeLogin, phone); err != nil {
+		t.Fatal(err)
+	}
+
+	message, ok := sms.DefaultFakeSender.LastMessage(phone)
+	if !ok {
+		t.Fatal("未收到验证码短信")
+	}
+	code := verifyCodePattern.FindString(message.Content)
+	if code == "" {
+		t.Fatalf("短信中没有验证码: %s", message.Content)
+	}
+
+	return code
+}
+
+// wrongCode 与正确验证码不同的验证码
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestCheckCode(t *testing.T) {
+	newMockRedis(t)
+	useFakeSMS(t)
+	var verificationService service.VerificationService
+
+	code := sendLoginCode(t, 7, "13800000000")
+	userID, err := verificationService.CheckCode(service.VerifyTypeLogin, " 13800000000 ", " "+code+" ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != 7 {
+		t.Errorf("userID = %d，期望 7", userID)
+	}
+
+	// 校验通过后验证码失效
+	if _, err := verificationService.CheckCode(service.VerifyTypeLogin, "13800000000", code); err == nil || err.Error() != "验证码无效或已过期" {
+		t.Errorf("重复使用验证码 err = %v", err)
+	}
+}
+
+// TestCheckCodeAttemptLimit 每个验证码最多校验 5 次，超过后验证码失效，重新发送后重新计数
+func TestCheckCodeAttemptLimit(t *testing.T) {
+	server := newMockRedis(t)
+	useFakeSMS(t)
+	var verificationService service.VerificationService
+	const phone = "13800000000"
+
+	code := sendLoginCode(t, 7, phone)
+	tests := []struct {
+		code    string
+		wantErr string
+	}{
+		{wrongCode(code), "验证码错误"},
+		{wrongCode(code), "验证码错误"},
+		{wrongCode(code), "验证码错误"},
+		{wrongCode(code), "验证码错误"},
+		{wrongCode(code), "验证码错误"},
+		{code, "验证次数过多，请重新获取验证码"},
+		{code, "验证码无效或已过期"},
+	}
+	for i, tt := range tests {
+		_, err := verificationService.CheckCode(service.VerifyTypeLogin, phone, tt.code)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Fatalf("第 %d 次校验 err = %v，期望 %s", i+1, err, tt.wantErr)
+		}
+	}
+
+	// 重新发送验证码后校验次数清零（跳过重发间隔限制）
+	server.FlushAll()
+	code = sendLoginCode(t, 7, phone)
+	for i := 0; i < 4; i++ {
+		if _, err := verificationService.CheckCode(service.VerifyTypeLogin, phone, wrongCode(code)); err == nil {
+			t.Fatal("错误的验证码应校验失败")
+		}
+	}
+	if _, err := verificationService.CheckCode(service.VerifyTypeLogin, phone, code); err != nil {
+		t.Errorf("第 5 次使用正确的验证码应校验通过: %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"ffly-baisc/internal/config"
 	"fmt"
 	"time"
@@ -8,79 +10,134 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	AccessTokenExpire    = 30 * time.Minute   // Access Token 有效期 30分钟
+	RefreshTokenExpire   = 2 * 24 * time.Hour // Refresh Token 有效期 2天
+	ChallengeTokenExpire = 5 * time.Minute    // 两步验证挑战 Token 有效期 5分钟
+)
+
 type Claims struct {
 	UserID    uint   `json:"user_id"`
 	Username  string `json:"username"`
 	TokenType string `json:"token_type"` // "access" 或 "refresh"
+	FamilyID  string `json:"fid"`        // 令牌族ID，同一次登录及其后续刷新产生的令牌属于同一族
+	// 模拟登录时的真实操作人，普通登录为空
+	ActorID       uint   `json:"actor_id,omitempty"`
+	ActorUsername string `json:"actor_username,omitempty"`
+	// RegisteredClaims.ID 即 jti，每个 Token 唯一
 	jwt.RegisteredClaims
 }
 
 // TokenPair Token 对
 type TokenPair struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ExpiresIn    int64  `json:"expiresIn"` // Access Token 过期时间（秒）
+	AccessToken    string `json:"accessToken"`
+	RefreshToken   string `json:"refreshToken"`
+	ExpiresIn      int64  `json:"expiresIn"` // Access Token 过期时间（秒）
+	AccessTokenID  string `json:"-"`         // Access Token 的 jti
+	RefreshTokenID string `json:"-"`         // Refresh Token 的 jti
+	FamilyID       string `json:"-"`         // 令牌族ID
+}
+
+// NewTokenID 生成随机的 Token ID
+// 令牌族轮换和重复使用检测依赖 ID 唯一，系统随机数不可用时直接 panic（同 uuid.New），不生成可预测的 ID
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("生成 Token ID 失败: %v", err))
+	}
+	return hex.EncodeToString(b)
 }
 
 // GenerateTokenPair 生成 Access Token 和 Refresh Token
-func GenerateTokenPair(userID uint, username string) (*TokenPair, error) {
+// familyID 为空时表示新的登录会话，会生成新的令牌族ID；刷新 Token 时传入原令牌族ID
+func GenerateTokenPair(userID uint, username string, familyID string) (*TokenPair, error) {
+	if familyID == "" {
+		familyID = NewTokenID()
+	}
+
 	// Access Token - 短期有效
-	accessToken, err := generateToken(userID, username, "access", 60*60*30) // 30分钟
+	accessTokenID := NewTokenID()
+	accessToken, err := generateToken(userID, username, "access", accessTokenID, familyID, AccessTokenExpire)
 	if err != nil {
 		return nil, err
 	}
 
 	// Refresh Token -
-	refreshToken, err := generateToken(userID, username, "refresh", 2*24*60*60) // 2天
+	refreshTokenID := NewTokenID()
+	refreshToken, err := generateToken(userID, username, "refresh", refreshTokenID, familyID, RefreshTokenExpire)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    30 * 60,
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		ExpiresIn:      int64(AccessTokenExpire.Seconds()),
+		AccessTokenID:  accessTokenID,
+		RefreshTokenID: refreshTokenID,
+		FamilyID:       familyID,
 	}, nil
 }
 
-// generateToken 生成指定类型的 Token
-func generateToken(userID uint, username string, tokenType string, expiresIn int64) (string, error) {
+// GenerateChallengeToken 生成两步验证的挑战 Token，只能用于 /login/2fa 换取 Token 对，返回 Token 及其 jti
+func GenerateChallengeToken(userID uint, username string) (string, string, error) {
+	tokenID := NewTokenID()
+	token, err := generateToken(userID, username, "2fa_challenge", tokenID, "", ChallengeTokenExpire)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, tokenID, nil
+}
+
+// GenerateImpersonationToken 生成模拟登录的 Access Token，不签发 Refresh Token，到期后需要重新发起
+// 返回 Token 及其 jti
+func GenerateImpersonationToken(userID uint, username string, actorID uint, actorUsername, familyID string, expiresIn time.Duration) (string, string, error) {
+	tokenID := NewTokenID()
 	claims := Claims{
-		UserID:    userID,
-		Username:  username,
-		TokenType: tokenType,
+		UserID:        userID,
+		Username:      username,
+		TokenType:     "access",
+		FamilyID:      familyID,
+		ActorID:       actorID,
+		ActorUsername: actorUsername,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiresIn) * time.Second)),
+			ID:        tokenID,
+			Issuer:    config.GlobalConfig.App.Name,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.GlobalConfig.App.JWTSecret))
-}
-
-// RefreshAccessToken 使用 Refresh Token 刷新 Access Token
-func RefreshAccessToken(refreshTokenString string) (*TokenPair, error) {
-	// 解析 Refresh Token
-	claims, err := ParseToken(refreshTokenString)
+	token, err := signToken(claims)
 	if err != nil {
-		return nil, fmt.Errorf("无效的 Refresh Token: %w", err)
+		return "", "", err
 	}
 
-	// 验证是否为 Refresh Token
-	if claims.TokenType != "refresh" {
-		return nil, fmt.Errorf("Token 类型错误，需要 Refresh Token")
+	return token, tokenID, nil
+}
+
+// generateToken 生成指定类型的 Token
+func generateToken(userID uint, username, tokenType, tokenID, familyID string, expiresIn time.Duration) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Username:  username,
+		TokenType: tokenType,
+		FamilyID:  familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    config.GlobalConfig.App.Name,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
-	// 生成新的 Token 对
-	return GenerateTokenPair(claims.UserID, claims.Username)
+	return signToken(claims)
 }
 
 // ParseToken 解析JWT token
 func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GlobalConfig.App.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verifyKey)
 
 	if err != nil {
 		return nil, err
@@ -92,19 +149,3 @@ func ParseToken(tokenString string) (*Claims, error) {
 
 	return nil, jwt.ErrSignatureInvalid
 }
-
-// GetUserRoles 根据用户ID获取用户角色列表
-func GetUserRoles(userID uint) ([]uint, error) {
-	// 这里需要导入数据库相关的包，为了避免循环依赖，建议在service层实现
-	// 或者创建一个专门的权限服务
-	return nil, nil
-}
-
-// HasPermission 检查用户是否有指定权限
-func HasPermission(userID uint, permission string) (bool, error) {
-	// 实现权限检查逻辑
-	// 1. 根据用户ID查询用户角色
-	// 2. 根据角色查询权限
-	// 3. 检查是否包含指定权限
-	return false, nil
-}
@@ -0,0 +1,407 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"ffly-baisc/internal/config"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256" // 对称签名，使用 app.jwt_secret
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	defaultKeyDir      = "keys"
+	defaultKeyRotation = 30 * 24 * time.Hour // 默认 30 天轮换一次
+	keyReloadInterval  = 10 * time.Second    // 未知 kid 触发重新加载目录的最小间隔，避免伪造的 kid 每次请求都读取目录
+)
+
+// SigningKey JWT 签名密钥
+type SigningKey struct {
+	ID         string        // kid
+	Algorithm  string        // RS256 / EdDSA
+	CreatedAt  time.Time     // 创建时间
+	PrivateKey crypto.Signer // 私钥
+}
+
+// JWK JSON Web Key（仅公钥）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA 模数
+	E   string `json:"e,omitempty"`   // RSA 指数
+	Crv string `json:"crv,omitempty"` // OKP 曲线
+	X   string `json:"x,omitempty"`   // OKP 公钥
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// keySet 签名密钥集合，密钥以 PEM 文件的形式保存在 app.jwt_key_dir 目录中，多实例部署时共享该目录即可
+type keySet struct {
+	mu         sync.RWMutex
+	keys       []*SigningKey // 按创建时间升序，最后一个为当前签名密钥
+	reloadedAt time.Time     // 上一次因未知 kid 重新加载目录的时间
+}
+
+var signingKeys = &keySet{}
+
+// SigningAlgorithm 获取配置的签名算法
+func SigningAlgorithm() string {
+	switch strings.ToUpper(config.GlobalConfig.App.JWTAlgorithm) {
+	case "RS256":
+		return AlgorithmRS256
+	case "EDDSA", "ED25519":
+		return AlgorithmEdDSA
+	default:
+		return AlgorithmHS256
+	}
+}
+
+// isAsymmetric 是否使用非对称签名
+func isAsymmetric() bool {
+	return SigningAlgorithm() != AlgorithmHS256
+}
+
+// keyDir 密钥存放目录
+func keyDir() string {
+	if config.GlobalConfig.App.JWTKeyDir == "" {
+		return defaultKeyDir
+	}
+	return config.GlobalConfig.App.JWTKeyDir
+}
+
+// keyRotation 密钥轮换周期
+func keyRotation() time.Duration {
+	if config.GlobalConfig.App.JWTKeyRotation <= 0 {
+		return defaultKeyRotation
+	}
+	return time.Duration(config.GlobalConfig.App.JWTKeyRotation) * time.Second
+}
+
+// InitKeys 初始化签名密钥，不存在或已到轮换时间时生成新密钥，HS256 模式下无需初始化
+func InitKeys() error {
+	if !isAsymmetric() {
+		return nil
+	}
+
+	_, err := signingKeys.currentKey()
+	return err
+}
+
+// GetJWKS 获取所有仍可用于验签的公钥
+func GetJWKS() (*JWKS, error) {
+	jwks := &JWKS{Keys: []JWK{}}
+	if !isAsymmetric() {
+		return jwks, nil
+	}
+
+	// 先确保密钥已按时轮换
+	if _, err := signingKeys.currentKey(); err != nil {
+		return nil, err
+	}
+
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	for _, key := range signingKeys.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+		switch publicKey := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
+// signToken 签名 Token
+func signToken(claims jwt.Claims) (string, error) {
+	if !isAsymmetric() {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.GlobalConfig.App.JWTSecret))
+	}
+
+	key, err := signingKeys.currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// verifyKey 获取验签密钥，用作 jwt.Keyfunc
+func verifyKey(token *jwt.Token) (interface{}, error) {
+	if !isAsymmetric() {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("不支持的签名算法: %v", token.Header["alg"])
+		}
+		return []byte(config.GlobalConfig.App.JWTSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := signingKeys.findKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 防止算法混淆攻击
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("签名算法与密钥不匹配: %v", token.Header["alg"])
+	}
+
+	return key.PrivateKey.Public(), nil
+}
+
+// signingMethod 根据算法获取签名方法
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// currentKey 获取当前签名密钥，超过轮换周期时生成新密钥
+func (ks *keySet) currentKey() (*SigningKey, error) {
+	ks.mu.RLock()
+	key := ks.latest()
+	ks.mu.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	// 重新从目录加载，其他实例可能已经完成了轮换
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	if key := ks.latest(); key != nil {
+		return key, nil
+	}
+
+	key, err := generateKey(SigningAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+	if err := saveKey(key); err != nil {
+		return nil, err
+	}
+	ks.keys = append(ks.keys, key)
+
+	return key, nil
+}
+
+// latest 获取最新且未到轮换时间的密钥，需持有锁
+func (ks *keySet) latest() *SigningKey {
+	if len(ks.keys) == 0 {
+		return nil
+	}
+
+	key := ks.keys[len(ks.keys)-1]
+	if key.Algorithm != SigningAlgorithm() || time.Since(key.CreatedAt) >= keyRotation() {
+		return nil
+	}
+
+	return key
+}
+
+// findKey 根据 kid 查找验签密钥，本地不存在时重新从目录加载，每 keyReloadInterval 最多加载一次
+func (ks *keySet) findKey(kid string) (*SigningKey, error) {
+	ks.mu.RLock()
+	key := ks.find(kid)
+	reloadedAt := ks.reloadedAt
+	ks.mu.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+	if time.Since(reloadedAt) < keyReloadInterval {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	// 等待写锁期间其他请求可能已经加载过
+	if key := ks.find(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(ks.reloadedAt) < keyReloadInterval {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+
+	ks.reloadedAt = time.Now()
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	if key := ks.find(kid); key != nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// find 根据 kid 查找密钥，需持有锁
+func (ks *keySet) find(kid string) *SigningKey {
+	for _, key := range ks.keys {
+		if key.ID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// load 从目录加载所有密钥，并删除已过期的旧密钥，需持有写锁
+// 旧密钥在被下一个密钥替换后，继续保留 Refresh Token 的有效期，保证其签发的 Token 在过期前都能验签
+func (ks *keySet) load() error {
+	files, err := filepath.Glob(filepath.Join(keyDir(), "*.pem"))
+	if err != nil {
+		return fmt.Errorf("读取密钥目录失败: %w", err)
+	}
+
+	keys := make([]*SigningKey, 0, len(files))
+	for _, file := range files {
+		key, err := loadKey(file)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	// 清理过期密钥
+	activeKeys := make([]*SigningKey, 0, len(keys))
+	for i, key := range keys {
+		if i < len(keys)-1 && time.Since(keys[i+1].CreatedAt) > RefreshTokenExpire {
+			os.Remove(filepath.Join(keyDir(), key.ID+".pem"))
+			continue
+		}
+		activeKeys = append(activeKeys, key)
+	}
+
+	ks.keys = activeKeys
+	return nil
+}
+
+// generateKey 生成新的签名密钥
+func generateKey(algorithm string) (*SigningKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+
+	now := time.Now()
+	return &SigningKey{
+		ID:         fmt.Sprintf("%s-%s", now.Format("20060102150405"), NewTokenID()[:8]),
+		Algorithm:  algorithm,
+		CreatedAt:  now,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// saveKey 以 PKCS#8 PEM 格式保存密钥，创建时间和算法写入 PEM 头
+func saveKey(key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("编码签名密钥失败: %w", err)
+	}
+
+	if err := os.MkdirAll(keyDir(), 0700); err != nil {
+		return fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			"Algorithm":  key.Algorithm,
+			"Created-At": key.CreatedAt.Format(time.RFC3339),
+		},
+		Bytes: der,
+	})
+
+	if err := os.WriteFile(filepath.Join(keyDir(), key.ID+".pem"), data, 0600); err != nil {
+		return fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+
+	return nil
+}
+
+// loadKey 读取 PEM 格式的密钥文件
+func loadKey(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取签名密钥失败: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("签名密钥格式错误: %s", file)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名密钥失败: %w", err)
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("签名密钥类型错误")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers["Created-At"])
+	if err != nil {
+		return nil, fmt.Errorf("签名密钥创建时间错误: %w", err)
+	}
+
+	return &SigningKey{
+		ID:         strings.TrimSuffix(filepath.Base(file), ".pem"),
+		Algorithm:  block.Headers["Algorithm"],
+		CreatedAt:  createdAt,
+		PrivateKey: signer,
+	}, nil
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"ffly-baisc/internal/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useKeys 使用临时目录作为密钥目录，并清空内存中的密钥，模拟一个新启动的实例
+func useKeys(t *testing.T, algorithm string, rotation time.Duration) string {
+	t.Helper()
+
+	app := config.GlobalConfig.App
+	t.Cleanup(func() {
+		config.GlobalConfig.App = app
+		signingKeys = &keySet{}
+	})
+
+	dir := t.TempDir()
+	config.GlobalConfig.App.Name = "ffly"
+	config.GlobalConfig.App.JWTSecret = "test-secret"
+	config.GlobalConfig.App.JWTAlgorithm = algorithm
+	config.GlobalConfig.App.JWTKeyDir = dir
+	config.GlobalConfig.App.JWTKeyRotation = int(rotation.Seconds())
+	signingKeys = &keySet{}
+
+	return dir
+}
+
+// saveKeyAt 生成并保存一个指定创建时间的密钥
+func saveKeyAt(t *testing.T, algorithm string, createdAt time.Time) *SigningKey {
+	t.Helper()
+
+	key, err := generateKey(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.CreatedAt = createdAt
+	if err := saveKey(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// jwkPublicKey 从 JWK 还原公钥
+func jwkPublicKey(t *testing.T, jwk JWK) any {
+	t.Helper()
+
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+
+	t.Fatalf("未知的 kty: %s", jwk.Kty)
+	return nil
+}
+
+// TestJWKSVerifiesToken 签发的 Token 可以用 JWKS 中对应 kid 的公钥验签
+func TestJWKSVerifiesToken(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		kty       string
+	}{
+		{AlgorithmRS256, "RSA"},
+		{AlgorithmEdDSA, "OKP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			useKeys(t, tt.algorithm, time.Hour)
+
+			tokenPair, err := GenerateTokenPair(1, "admin", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jwks, err := GetJWKS()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("JWKS 密钥数量 = %d，期望 1", len(jwks.Keys))
+			}
+			jwk := jwks.Keys[0]
+			if jwk.Kty != tt.kty || jwk.Alg != tt.algorithm || jwk.Use != "sig" {
+				t.Errorf("JWK = %+v", jwk)
+			}
+
+			token, err := jwt.ParseWithClaims(tokenPair.AccessToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+				if token.Header["kid"] != jwk.Kid {
+					t.Errorf("Token kid = %v，期望 %s", token.Header["kid"], jwk.Kid)
+				}
+				return jwkPublicKey(t, jwk), nil
+			}, jwt.WithValidMethods([]string{tt.algorithm}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims := token.Claims.(*Claims); claims.UserID != 1 || claims.ID != tokenPair.AccessTokenID {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestJWKSEmptyForHS256(t *testing.T) {
+	useKeys(t, AlgorithmHS256, time.Hour)
+
+	jwks, err := GetJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 0 {
+		t.Errorf("HS256 模式下 JWKS 应为空，实际 %d 个", len(jwks.Keys))
+	}
+}
+
+// TestKeyRotation 到轮换时间后生成新密钥，旧密钥继续发布在 JWKS 中，其签发的 Token 仍可验签
+func TestKeyRotation(t *testing.T) {
+	useKeys(t, AlgorithmEdDSA, time.Hour)
+	oldKey := saveKeyAt(t, AlgorithmEdDSA, time.Now().Add(-2*time.Hour))
+
+	// 用旧密钥签发一个 Token
+	oldToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, Claims{UserID: 1, TokenType: "access"})
+	oldToken.Header["kid"] = oldKey.ID
+	oldTokenString, err := oldToken.SignedString(oldKey.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := signingKeys.currentKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ID == oldKey.ID {
+		t.Fatal("超过轮换周期后应生成新密钥")
+	}
+
+	jwks, err := GetJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != oldKey.ID || jwks.Keys[1].Kid != current.ID {
+		t.Fatalf("JWKS = %+v，期望旧密钥 %s 和新密钥 %s", jwks.Keys, oldKey.ID, current.ID)
+	}
+
+	if _, err := ParseToken(oldTokenString); err != nil {
+		t.Errorf("旧密钥签发的 Token 应仍可验签: %v", err)
+	}
+}
+
+// TestKeyRotationRemovesExpiredKeys 被替换超过 Refresh Token 有效期的旧密钥会被删除
+func TestKeyRotationRemovesExpiredKeys(t *testing.T) {
+	dir := useKeys(t, AlgorithmEdDSA, 24*time.Hour)
+	expiredKey := saveKeyAt(t, AlgorithmEdDSA, time.Now().Add(-10*24*time.Hour))
+	currentKey := saveKeyAt(t, AlgorithmEdDSA, time.Now().Add(-RefreshTokenExpire-time.Hour))
+	latestKey := saveKeyAt(t, AlgorithmEdDSA, time.Now())
+
+	jwks, err := GetJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != currentKey.ID || jwks.Keys[1].Kid != latestKey.ID {
+		t.Fatalf("JWKS = %+v，期望 %s 和 %s", jwks.Keys, currentKey.ID, latestKey.ID)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, expiredKey.ID+".pem")); !os.IsNotExist(err) {
+		t.Errorf("过期密钥文件应被删除: %v", err)
+	}
+}
+
+// TestFindKeyLoadsFromDir 其他实例生成的密钥，本实例按 kid 从密钥目录重新加载
+func TestFindKeyLoadsFromDir(t *testing.T) {
+	useKeys(t, AlgorithmRS256, time.Hour)
+
+	tokenPair, err := GenerateTokenPair(1, "admin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟另一个实例：内存中没有密钥，共享同一个密钥目录
+	signingKeys = &keySet{}
+	claims, err := ParseToken(tokenPair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID != tokenPair.AccessTokenID {
+		t.Errorf("jti = %s，期望 %s", claims.ID, tokenPair.AccessTokenID)
+	}
+}
+
+func TestParseTokenRejectsWrongKey(t *testing.T) {
+	useKeys(t, AlgorithmRS256, time.Hour)
+
+	key, err := signingKeys.currentKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 算法混淆：使用 HS256 并指定 RSA 密钥的 kid
+	hsToken := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: 1})
+	hsToken.Header["kid"] = key.ID
+	hsTokenString, err := hsToken.SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(hsTokenString); err == nil {
+		t.Error("算法与密钥不匹配的 Token 应验签失败")
+	}
+
+	// 未知的 kid
+	unknownKey, err := generateKey(AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownToken := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{UserID: 1})
+	unknownToken.Header["kid"] = unknownKey.ID
+	unknownTokenString, err := unknownToken.SignedString(unknownKey.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(unknownTokenString); err == nil {
+		t.Error("未知 kid 的 Token 应验签失败")
+	}
+}
+
+// TestFindKeyRateLimitsReload 未知 kid 每 keyReloadInterval 最多重新加载一次目录
+func TestFindKeyRateLimitsReload(t *testing.T) {
+	useKeys(t, AlgorithmRS256, time.Hour)
+
+	if _, err := signingKeys.findKey("unknown"); err == nil {
+		t.Fatal("未知 kid 应查找失败")
+	}
+
+	// 间隔内不重新加载，其他实例新生成的密钥暂时找不到
+	key := saveKeyAt(t, AlgorithmRS256, time.Now())
+	if _, err := signingKeys.findKey(key.ID); err == nil {
+		t.Error("间隔内不应重新加载目录")
+	}
+
+	signingKeys.reloadedAt = time.Now().Add(-keyReloadInterval)
+	found, err := signingKeys.findKey(key.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != key.ID {
+		t.Errorf("kid = %s，期望 %s", found.ID, key.ID)
+	}
+}
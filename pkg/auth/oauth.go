@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	OAuthAccessTokenExpire = time.Hour // 授权服务器签发的 Access Token 有效期 1小时
+	IDTokenExpire          = time.Hour // ID Token 有效期 1小时
+)
+
+// OAuthClaims 授权服务器签发给第三方应用的 Access Token 声明
+// token_type 为 oauth_access，不能用于访问本系统的管理接口
+type OAuthClaims struct {
+	TokenType   string   `json:"token_type"`
+	ClientID    string   `json:"client_id"`
+	Scope       string   `json:"scope,omitempty"`
+	Roles       []string `json:"roles,omitempty"`       // 角色编码（scope 包含 roles 时）
+	Permissions []string `json:"permissions,omitempty"` // 权限码（scope 包含 permissions 时）
+	jwt.RegisteredClaims
+}
+
+// GenerateOAuthAccessToken 生成授权服务器的 Access Token
+func GenerateOAuthAccessToken(claims *OAuthClaims) (string, error) {
+	claims.TokenType = "oauth_access"
+	claims.ID = NewTokenID()
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(OAuthAccessTokenExpire))
+
+	return signToken(claims)
+}
+
+// GenerateIDToken 生成 OIDC ID Token
+func GenerateIDToken(claims jwt.MapClaims) (string, error) {
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(IDTokenExpire).Unix()
+
+	return signToken(claims)
+}
+
+// ParseOAuthAccessToken 解析授权服务器签发的 Access Token
+func ParseOAuthAccessToken(tokenString, issuer string) (*OAuthClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &OAuthClaims{}, verifyKey, jwt.WithIssuer(issuer))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*OAuthClaims)
+	if !ok || !token.Valid || claims.TokenType != "oauth_access" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,145 @@
+package captcha
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// glyphs 5x7 点阵字体，只包含验证码用到的字符
+var glyphs = map[rune][glyphHeight]string{
+	'0': {
+		".###.",
+		"#...#",
+		"#..##",
+		"#.#.#",
+		"##..#",
+		"#...#",
+		".###.",
+	},
+	'1': {
+		"..#..",
+		".##..",
+		"..#..",
+		"..#..",
+		"..#..",
+		"..#..",
+		".###.",
+	},
+	'2': {
+		".###.",
+		"#...#",
+		"....#",
+		"...#.",
+		"..#..",
+		".#...",
+		"#####",
+	},
+	'3': {
+		"#####",
+		"...#.",
+		"..#..",
+		"...#.",
+		"....#",
+		"#...#",
+		".###.",
+	},
+	'4': {
+		"...#.",
+		"..##.",
+		".#.#.",
+		"#..#.",
+		"#####",
+		"...#.",
+		"...#.",
+	},
+	'5': {
+		"#####",
+		"#....",
+		"####.",
+		"....#",
+		"....#",
+		"#...#",
+		".###.",
+	},
+	'6': {
+		"..##.",
+		".#...",
+		"#....",
+		"####.",
+		"#...#",
+		"#...#",
+		".###.",
+	},
+	'7': {
+		"#####",
+		"....#",
+		"...#.",
+		"..#..",
+		".#...",
+		".#...",
+		".#...",
+	},
+	'8': {
+		".###.",
+		"#...#",
+		"#...#",
+		".###.",
+		"#...#",
+		"#...#",
+		".###.",
+	},
+	'9': {
+		".###.",
+		"#...#",
+		"#...#",
+		".####",
+		"....#",
+		"...#.",
+		".##..",
+	},
+	'+': {
+		".....",
+		"..#..",
+		"..#..",
+		"#####",
+		"..#..",
+		"..#..",
+		".....",
+	},
+	'-': {
+		".....",
+		".....",
+		".....",
+		"#####",
+		".....",
+		".....",
+		".....",
+	},
+	'x': {
+		".....",
+		"#...#",
+		".#.#.",
+		"..#..",
+		".#.#.",
+		"#...#",
+		".....",
+	},
+	'=': {
+		".....",
+		".....",
+		"#####",
+		".....",
+		"#####",
+		".....",
+		".....",
+	},
+	'?': {
+		".###.",
+		"#...#",
+		"....#",
+		"...#.",
+		"..#..",
+		".....",
+		"..#..",
+	},
+}
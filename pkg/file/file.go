@@ -69,6 +69,15 @@ func writeData(file *excelize.File, sheetName string, row *int, level int, dataV
 				prefix = strings.Repeat(column.Prefix, level) // 重复前缀
 			}
 
+			// 指针类型取指向的值，nil 导出为空
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field = reflect.ValueOf("")
+				} else {
+					field = field.Elem()
+				}
+			}
+
 			// 获取字段值并处理前缀
 			var value interface{}
 			if field.Kind() == reflect.String { // 如果是字符串类型
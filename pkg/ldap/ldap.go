@@ -0,0 +1,230 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrUserNotFound       = errors.New("目录中不存在该用户")
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+)
+
+// Config LDAP / Active Directory 连接配置
+type Config struct {
+	URL                string        // 服务地址，如 ldap://127.0.0.1:389、ldaps://ad.example.com:636
+	StartTLS           bool          // ldap:// 连接后升级为 TLS
+	InsecureSkipVerify bool          // 跳过证书校验，仅用于测试环境
+	BindDN             string        // 服务账号 DN，用于搜索用户，为空时匿名搜索
+	BindPassword       string        // 服务账号密码
+	BaseDN             string        // 用户搜索根
+	UserFilter         string        // 用户搜索过滤器，%s 替换为转义后的用户名，默认 (uid=%s)
+	UsernameAttribute  string        // 用户名属性，默认 uid
+	EmailAttribute     string        // 邮箱属性，默认 mail
+	NicknameAttribute  string        // 昵称属性，默认 cn
+	GroupAttribute     string        // 用户条目上的组属性，默认 memberOf
+	GroupBaseDN        string        // 组搜索根，为空时不搜索组（只使用 GroupAttribute）
+	GroupFilter        string        // 组搜索过滤器，%s 替换为转义后的用户 DN，默认 (member=%s)
+	Timeout            time.Duration // 连接和请求超时，默认 5 秒
+}
+
+// Entry 认证通过的目录用户
+type Entry struct {
+	DN       string
+	Username string
+	Email    string
+	Nickname string
+	Groups   []string // 所属组的 DN
+}
+
+// Client LDAP 认证客户端，每次认证建立新连接
+type Client struct {
+	config Config
+}
+
+// NewClient 创建客户端，未配置的属性使用 OpenLDAP 常用默认值
+func NewClient(config Config) *Client {
+	if config.UserFilter == "" {
+		config.UserFilter = "(uid=%s)"
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = "uid"
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = "mail"
+	}
+	if config.NicknameAttribute == "" {
+		config.NicknameAttribute = "cn"
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	if config.GroupFilter == "" {
+		config.GroupFilter = "(member=%s)"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	return &Client{config: config}
+}
+
+// Authenticate 使用服务账号搜索用户 DN，再以用户 DN 和密码绑定校验，返回用户信息和所属组
+func (c *Client) Authenticate(username, password string) (*Entry, error) {
+	// 空密码会被服务端当作匿名绑定并返回成功，必须拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := c.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(goldap.NewSearchRequest(
+		c.config.BaseDN,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, int(c.config.Timeout.Seconds()), false,
+		fmt.Sprintf(c.config.UserFilter, goldap.EscapeFilter(username)),
+		[]string{c.config.UsernameAttribute, c.config.EmailAttribute, c.config.NicknameAttribute, c.config.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+			return nil, errors.New("目录中匹配到多个用户，请检查用户搜索过滤器")
+		}
+		return nil, fmt.Errorf("搜索目录用户失败: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	if len(result.Entries) > 1 {
+		return nil, errors.New("目录中匹配到多个用户，请检查用户搜索过滤器")
+	}
+
+	userEntry := result.Entries[0]
+	if err := conn.Bind(userEntry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("目录用户绑定失败: %w", err)
+	}
+
+	entry := &Entry{
+		DN:       userEntry.DN,
+		Username: userEntry.GetAttributeValue(c.config.UsernameAttribute),
+		Email:    userEntry.GetAttributeValue(c.config.EmailAttribute),
+		Nickname: userEntry.GetAttributeValue(c.config.NicknameAttribute),
+		Groups:   userEntry.GetAttributeValues(c.config.GroupAttribute),
+	}
+	if entry.Username == "" {
+		entry.Username = username
+	}
+
+	// 目录不支持 memberOf 时按组成员关系搜索
+	if c.config.GroupBaseDN != "" {
+		groups, err := c.searchGroups(conn, userEntry.DN)
+		if err != nil {
+			return nil, err
+		}
+		entry.Groups = append(entry.Groups, groups...)
+	}
+
+	return entry, nil
+}
+
+// dial 建立连接，按配置升级 TLS
+func (c *Client) dial() (*goldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.config.InsecureSkipVerify}
+	if u, err := url.Parse(c.config.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := goldap.DialURL(c.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: c.config.Timeout}),
+		goldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("连接目录服务失败: %w", err)
+	}
+	conn.SetTimeout(c.config.Timeout)
+
+	if c.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("目录服务 StartTLS 失败: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// bindService 以服务账号绑定，未配置服务账号时保持匿名
+func (c *Client) bindService(conn *goldap.Conn) error {
+	if c.config.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		return fmt.Errorf("目录服务账号绑定失败: %w", err)
+	}
+	return nil
+}
+
+// searchGroups 搜索包含该用户的组，返回组 DN
+// 用户绑定后可能没有读取组的权限，先切回服务账号
+func (c *Client) searchGroups(conn *goldap.Conn, userDN string) ([]string, error) {
+	if err := c.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(goldap.NewSearchRequest(
+		c.config.GroupBaseDN,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, int(c.config.Timeout.Seconds()), false,
+		fmt.Sprintf(c.config.GroupFilter, goldap.EscapeFilter(userDN)),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("搜索目录组失败: %w", err)
+	}
+
+	groups := make([]string, 0, len(result.Entries))
+	for _, groupEntry := range result.Entries {
+		groups = append(groups, groupEntry.DN)
+	}
+
+	return groups, nil
+}
+
+// GroupMatches 判断组 DN 是否与配置的组匹配，配置可以是完整 DN 或组名（CN），不区分大小写
+func GroupMatches(groupDN, group string) bool {
+	if strings.EqualFold(groupDN, group) {
+		return true
+	}
+
+	dn, err := goldap.ParseDN(groupDN)
+	if err != nil || len(dn.RDNs) == 0 {
+		return false
+	}
+	for _, attribute := range dn.RDNs[0].Attributes {
+		if strings.EqualFold(attribute.Type, "cn") && strings.EqualFold(attribute.Value, group) {
+			return true
+		}
+	}
+	if other, err := goldap.ParseDN(group); err == nil && len(other.RDNs) > 0 {
+		return dn.EqualFold(other)
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package ldap_test
+
+import (
+	"errors"
+	"ffly-baisc/pkg/ldap"
+	"ffly-baisc/pkg/ldap/ldaptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	serviceDN = "cn=admin,dc=example,dc=com"
+	aliceDN   = "uid=alice,ou=people,dc=example,dc=com"
+	bobDN     = "uid=bob,ou=people,dc=example,dc=com"
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+	devsDN    = "cn=developers,ou=groups,dc=example,dc=com"
+)
+
+// newDirectory 启动包含服务账号、两个用户和一个组的目录
+// alice 通过 memberOf 属性属于 admins，bob 只出现在 developers 组的 member 属性中
+func newDirectory(t *testing.T) *ldaptest.Server {
+	t.Helper()
+
+	server := ldaptest.NewServer(
+		&ldaptest.Entry{DN: serviceDN, Password: "service-password"},
+		&ldaptest.Entry{DN: aliceDN, Password: "alice-password", Attributes: map[string][]string{
+			"uid":      {"alice"},
+			"mail":     {"alice@example.com"},
+			"cn":       {"Alice"},
+			"memberOf": {adminsDN},
+		}},
+		&ldaptest.Entry{DN: bobDN, Password: "bob-password", Attributes: map[string][]string{
+			"uid": {"bob"},
+		}},
+		&ldaptest.Entry{DN: devsDN, Attributes: map[string][]string{
+			"cn":     {"developers"},
+			"member": {bobDN},
+		}},
+	)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newClient(server *ldaptest.Server, config ldap.Config) *ldap.Client {
+	config.URL = server.URL()
+	config.BindDN = serviceDN
+	if config.BindPassword == "" {
+		config.BindPassword = "service-password"
+	}
+	config.BaseDN = "ou=people,dc=example,dc=com"
+	config.Timeout = 2 * time.Second
+
+	return ldap.NewClient(config)
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := newDirectory(t)
+
+	entry, err := newClient(server, ldap.Config{}).Authenticate("alice", "alice-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ldap.Entry{DN: aliceDN, Username: "alice", Email: "alice@example.com", Nickname: "Alice", Groups: []string{adminsDN}}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("entry = %+v，期望 %+v", entry, want)
+	}
+}
+
+// TestAuthenticateGroupSearch 配置组搜索根时按组的 member 属性搜索用户所属组
+func TestAuthenticateGroupSearch(t *testing.T) {
+	server := newDirectory(t)
+
+	client := newClient(server, ldap.Config{GroupBaseDN: "ou=groups,dc=example,dc=com"})
+	entry, err := client.Authenticate("bob", "bob-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entry.Groups, []string{devsDN}) {
+		t.Errorf("Groups = %v，期望 [%s]", entry.Groups, devsDN)
+	}
+
+	filters := server.Filters()
+	if len(filters) != 2 || filters[1] != "(member="+bobDN+")" {
+		t.Errorf("搜索过滤器 = %v", filters)
+	}
+}
+
+func TestAuthenticateBindFailure(t *testing.T) {
+	server := newDirectory(t)
+
+	tests := []struct {
+		name     string
+		client   *ldap.Client
+		username string
+		password string
+		want     error
+	}{
+		{"用户密码错误", newClient(server, ldap.Config{}), "alice", "wrong", ldap.ErrInvalidCredentials},
+		{"空密码不能匿名绑定", newClient(server, ldap.Config{}), "alice", "", ldap.ErrInvalidCredentials},
+		{"用户不存在", newClient(server, ldap.Config{}), "carol", "carol-password", ldap.ErrUserNotFound},
+		{"服务账号密码错误", newClient(server, ldap.Config{BindPassword: "wrong"}), "alice", "alice-password", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.Authenticate(tt.username, tt.password)
+			if err == nil {
+				t.Fatal("认证应失败")
+			}
+			// 服务账号绑定失败是配置错误，不能当作用户凭证错误
+			if tt.want == nil && (errors.Is(err, ldap.ErrInvalidCredentials) || errors.Is(err, ldap.ErrUserNotFound)) {
+				t.Errorf("err = %v，不应是用户凭证错误", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v，期望 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuthenticateEscapesFilter 用户名中的过滤器特殊字符被转义，不能匹配到其他用户
+func TestAuthenticateEscapesFilter(t *testing.T) {
+	server := newDirectory(t)
+
+	tests := []struct {
+		username string
+		filter   string
+	}{
+		{"*", `(uid=\2a)`},
+		{"alice)(uid=*", `(uid=alice\29\28uid=\2a)`},
+		{"*)(|(uid=*", `(uid=\2a\29\28|\28uid=\2a)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			_, err := newClient(server, ldap.Config{}).Authenticate(tt.username, "alice-password")
+			if !errors.Is(err, ldap.ErrUserNotFound) {
+				t.Errorf("err = %v，期望 %v", err, ldap.ErrUserNotFound)
+			}
+
+			filters := server.Filters()
+			if got := filters[len(filters)-1]; got != tt.filter {
+				t.Errorf("搜索过滤器 = %s，期望 %s", got, tt.filter)
+			}
+		})
+	}
+}
+
+func TestGroupMatches(t *testing.T) {
+	tests := []struct {
+		group string
+		want  bool
+	}{
+		{adminsDN, true},
+		{"CN=Admins,OU=Groups,DC=example,DC=com", true},
+		{"admins", true},
+		{"ADMINS", true},
+		{"groups", false},
+		{devsDN, false},
+	}
+	for _, tt := range tests {
+		if got := ldap.GroupMatches(adminsDN, tt.group); got != tt.want {
+			t.Errorf("GroupMatches(%q) = %v，期望 %v", tt.group, got, tt.want)
+		}
+	}
+}
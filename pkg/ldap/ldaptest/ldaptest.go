@@ -0,0 +1,221 @@
+// Package ldaptest 提供测试用的进程内 LDAP 服务（简单绑定和搜索），只用于测试
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// Entry 目录条目
+type Entry struct {
+	DN         string
+	Password   string              // 为空时不能绑定
+	Attributes map[string][]string // 属性名不区分大小写
+}
+
+// Server 测试用的 LDAP 服务，支持简单绑定和搜索（过滤器支持与、或、非、等值和存在）
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	entries []*Entry
+	filters []string // 收到的搜索过滤器
+}
+
+// NewServer 启动 LDAP 服务，使用完毕后需要调用 Close
+func NewServer(entries ...*Entry) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{listener: listener, entries: entries}
+	go s.serve()
+
+	return s
+}
+
+// URL 服务地址
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// Close 关闭服务
+func (s *Server) Close() {
+	s.listener.Close()
+}
+
+// Filters 收到的搜索过滤器，按 RFC 4515 格式
+func (s *Server) Filters() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.filters...)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			s.write(conn, messageID, goldap.ApplicationBindResponse, s.bind(request))
+		case goldap.ApplicationSearchRequest:
+			entries, resultCode := s.search(request)
+			for _, entry := range entries {
+				s.writeEntry(conn, messageID, entry)
+			}
+			s.write(conn, messageID, goldap.ApplicationSearchResultDone, resultCode)
+		default:
+			// 解绑或不支持的请求，关闭连接
+			return
+		}
+	}
+}
+
+// bind 简单绑定，DN 为空时为匿名绑定
+func (s *Server) bind(request *ber.Packet) uint16 {
+	dn := request.Children[1].Data.String()
+	password := request.Children[2].Data.String()
+	if dn == "" && password == "" {
+		return goldap.LDAPResultSuccess
+	}
+
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return goldap.LDAPResultSuccess
+		}
+	}
+
+	return goldap.LDAPResultInvalidCredentials
+}
+
+// search 在 baseDN 下按过滤器搜索，超过数量限制时返回前 sizeLimit 条和 sizeLimitExceeded
+func (s *Server) search(request *ber.Packet) ([]*Entry, uint16) {
+	baseDN := strings.ToLower(request.Children[0].Data.String())
+	sizeLimit := int(request.Children[3].Value.(int64))
+	filter := request.Children[6]
+
+	if decompiled, err := goldap.DecompileFilter(filter); err == nil {
+		s.mu.Lock()
+		s.filters = append(s.filters, decompiled)
+		s.mu.Unlock()
+	}
+
+	var entries []*Entry
+	for _, entry := range s.entries {
+		if strings.HasSuffix(strings.ToLower(entry.DN), baseDN) && matches(entry, filter) {
+			entries = append(entries, entry)
+		}
+	}
+	if sizeLimit > 0 && len(entries) > sizeLimit {
+		return entries[:sizeLimit], goldap.LDAPResultSizeLimitExceeded
+	}
+
+	return entries, goldap.LDAPResultSuccess
+}
+
+// matches 条目是否匹配过滤器，不支持的过滤器类型视为不匹配
+func matches(entry *Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(entry, child) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if matches(entry, child) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(entry, filter.Children[0])
+	case goldap.FilterEqualityMatch:
+		for _, value := range attributeValues(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterPresent:
+		return len(attributeValues(entry, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+// attributeValues 属性值，属性名不区分大小写
+func attributeValues(entry *Entry, name string) []string {
+	if strings.EqualFold(name, "objectClass") {
+		return []string{"top"}
+	}
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeEntry(conn net.Conn, messageID any, entry *Entry) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	response.AppendChild(attributes)
+
+	conn.Write(envelope(messageID, response).Bytes())
+}
+
+// write 发送只包含结果码的响应
+func (s *Server) write(conn net.Conn, messageID any, tag ber.Tag, resultCode uint16) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, uint64(resultCode), "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, goldap.LDAPResultCodeMap[resultCode], "Diagnostic Message"))
+
+	conn.Write(envelope(messageID, response).Bytes())
+}
+
+func envelope(messageID any, response *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	packet.AppendChild(response)
+
+	return packet
+}
@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"ffly-baisc/internal/config"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message 邮件内容
+type Message struct {
+	To      []string // 收件人
+	Subject string   // 主题
+	Body    string   // 正文（纯文本）
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer 根据配置创建邮件发送器，mail.driver 为 smtp 时通过 SMTP 发送，否则仅打印日志
+func NewMailer() Mailer {
+	mailConfig := config.GlobalConfig.Mail
+	if mailConfig.Driver == "smtp" {
+		return &SMTPMailer{
+			Host:     mailConfig.Host,
+			Port:     mailConfig.Port,
+			Username: mailConfig.Username,
+			Password: mailConfig.Password,
+			From:     mailConfig.From,
+		}
+	}
+
+	return &LogMailer{}
+}
+
+// LogMailer 仅打印日志的邮件发送器，用于开发环境
+type LogMailer struct{}
+
+// Send 打印邮件内容
+func (m *LogMailer) Send(msg *Message) error {
+	log.Printf("[mail] to: %s, subject: %s\n%s\n", strings.Join(msg.To, ","), msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPMailer SMTP 邮件发送器
+// 端口 465 使用隐式 TLS，其他端口在服务器支持时使用 STARTTLS；未配置用户名时不进行认证（如本地 MailHog）
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(msg *Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("发件人格式错误: %w", err)
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+
+	var conn net.Conn
+	if m.Port == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, &tls.Config{ServerName: m.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 10*time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return fmt.Errorf("邮件服务器认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("设置发件人失败: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("设置收件人失败: %w", err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := writer.Write(buildMessage(m.From, msg)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// buildMessage 构造邮件报文
+func buildMessage(from string, msg *Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	builder.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(msg.Body) // 换行符由 smtp 的 DotWriter 统一转换为 CRLF
+	return []byte(builder.String())
+}
@@ -0,0 +1,174 @@
+package mail_test
+
+import (
+	"encoding/base64"
+	"ffly-baisc/pkg/mail"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// smtpSession 测试 SMTP 服务器收到的一次会话
+type smtpSession struct {
+	auth string   // AUTH PLAIN 解码后的凭据
+	from string   // MAIL FROM
+	to   []string // RCPT TO
+	data string   // 邮件报文
+}
+
+// newSMTPServer 启动只处理一个连接的 SMTP 服务器，拒绝 rejectRcpt 收件人
+// 返回端口和会话，会话在连接结束后发送
+func newSMTPServer(t *testing.T, rejectRcpt string) (int, <-chan *smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan *smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		session := &smtpSession{}
+		defer func() { sessions <- session }()
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(command) {
+			case "EHLO":
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+				session.auth = string(credentials)
+				text.PrintfLine("235 Authentication successful")
+			case "MAIL":
+				session.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+				if to == rejectRcpt {
+					text.PrintfLine("550 No such user")
+					continue
+				}
+				session.to = append(session.to, to)
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				session.data = string(data)
+				text.PrintfLine("250 OK")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, sessions
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		wantAuth string
+	}{
+		{"认证", "mailer", "\x00mailer\x00mail-password"},
+		{"不认证", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, sessions := newSMTPServer(t, "")
+			mailer := &mail.SMTPMailer{
+				Host:     "127.0.0.1",
+				Port:     port,
+				Username: tt.username,
+				Password: "mail-password",
+				From:     "FFLY <noreply@example.com>",
+			}
+
+			err := mailer.Send(&mail.Message{
+				To:      []string{"alice@example.com", "bob@example.com"},
+				Subject: "重置密码",
+				Body:    "第一行\n第二行",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			session := <-sessions
+			if session.auth != tt.wantAuth {
+				t.Errorf("认证凭据 = %q，期望 %q", session.auth, tt.wantAuth)
+			}
+			if session.from != "noreply@example.com" || strings.Join(session.to, ",") != "alice@example.com,bob@example.com" {
+				t.Errorf("发件人 = %s，收件人 = %v", session.from, session.to)
+			}
+			// ReadDotBytes 已把 CRLF 转换为 LF
+			for _, want := range []string{
+				"From: FFLY <noreply@example.com>\n",
+				"To: alice@example.com, bob@example.com\n",
+				"Subject: =?UTF-8?b?6YeN572u5a+G56CB?=\n",
+				"Content-Type: text/plain; charset=UTF-8\n",
+				"\n\n第一行\n第二行",
+			} {
+				if !strings.Contains(session.data, want) {
+					t.Errorf("邮件报文缺少 %q:\n%s", want, session.data)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPMailerSendErrors(t *testing.T) {
+	t.Run("收件人被拒绝", func(t *testing.T) {
+		port, _ := newSMTPServer(t, "nobody@example.com")
+		mailer := &mail.SMTPMailer{Host: "127.0.0.1", Port: port, From: "noreply@example.com"}
+		if err := mailer.Send(&mail.Message{To: []string{"nobody@example.com"}, Subject: "s", Body: "b"}); err == nil {
+			t.Error("收件人被拒绝时应返回错误")
+		}
+	})
+
+	t.Run("连接失败", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		mailer := &mail.SMTPMailer{Host: "127.0.0.1", Port: port, From: "noreply@example.com"}
+		if err := mailer.Send(&mail.Message{To: []string{"alice@example.com"}}); err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+			t.Errorf("err = %v，期望连接失败", err)
+		}
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		mailer := &mail.SMTPMailer{Host: "127.0.0.1", Port: 25, From: "not-an-address"}
+		if err := mailer.Send(&mail.Message{}); err == nil {
+			t.Error("收件人为空时应返回错误")
+		}
+		if err := mailer.Send(&mail.Message{To: []string{"alice@example.com"}}); err == nil {
+			t.Error("发件人格式错误时应返回错误")
+		}
+	})
+}
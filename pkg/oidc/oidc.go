@@ -0,0 +1,382 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksRefreshInterval = time.Minute // 遇到未知 kid 时重新拉取 JWKS 的最小间隔
+	clockSkew           = time.Minute // 允许的时钟误差
+)
+
+// Config OIDC 身份提供方配置
+type Config struct {
+	Issuer       string   // 颁发者地址，用于拼接 /.well-known/openid-configuration
+	ClientID     string   // 客户端ID
+	ClientSecret string   // 客户端密钥，公共客户端可为空（仅使用 PKCE）
+	RedirectURL  string   // 回调地址
+	Scopes       []string // 请求的 scope，默认 openid profile email
+}
+
+// Metadata 身份提供方元数据（OpenID Provider Metadata）
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse 令牌端点响应
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// Provider OIDC 身份提供方（授权码 + PKCE）
+// 元数据和 JWKS 在首次使用时拉取并缓存
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]any
+	keysFetchedAt time.Time
+}
+
+// NewProvider 创建身份提供方，httpClient 为空时使用默认客户端
+func NewProvider(config Config, httpClient *http.Client) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Provider{config: config, httpClient: httpClient}
+}
+
+// Metadata 获取身份提供方元数据
+func (p *Provider) Metadata() (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.discover()
+}
+
+// discover 拉取元数据，调用方需持有锁
+func (p *Provider) discover() (*Metadata, error) {
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := p.getJSON(strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("获取 OIDC 元数据失败: %w", err)
+	}
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OIDC 元数据 issuer 不匹配: %s", metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("OIDC 元数据不完整")
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// AuthCodeURL 生成授权地址
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.Metadata()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange 使用授权码和 PKCE code_verifier 换取令牌
+func (p *Provider) Exchange(code, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := p.Metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求令牌端点失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("令牌端点返回错误: %d %s", resp.StatusCode, body)
+	}
+
+	var tokenResponse TokenResponse
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("解析令牌响应失败: %w", err)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("令牌响应中缺少 id_token")
+	}
+
+	return &tokenResponse, nil
+}
+
+// VerifyIDToken 校验 ID Token 的签名、issuer、audience、有效期和 nonce，返回声明
+func (p *Provider) VerifyIDToken(rawIDToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("ID Token nonce 不匹配")
+	}
+
+	// 存在多个 audience 时 azp 必须为当前客户端
+	if aud, ok := claims["aud"].([]any); ok && len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, errors.New("ID Token azp 不匹配")
+		}
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("ID Token 缺少 sub")
+	}
+
+	return claims, nil
+}
+
+// keyFunc 根据 kid 从 JWKS 中查找公钥，找不到时重新拉取（密钥轮换）
+func (p *Provider) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+	if err := p.fetchKeys(); err != nil {
+		return nil, err
+	}
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// lookupKey 查找公钥，未指定 kid 且只有一个密钥时直接使用，调用方需持有锁
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys 拉取 JWKS，调用方需持有锁
+func (p *Provider) fetchKeys() error {
+	metadata, err := p.discover()
+	if err != nil {
+		return err
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(metadata.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("获取 JWKS 失败: %w", err)
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// 跳过不支持的密钥类型
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	return nil
+}
+
+// getJSON GET 请求并解析 JSON
+func (p *Provider) getJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 返回 %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// jwk JSON Web Key，支持 RSA、EC、OKP(Ed25519)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey 转换为 Go 公钥
+func (k *jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("Ed25519 公钥长度错误")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+}
+
+// RandomString 生成 URL 安全的随机字符串，用于 state、nonce、code_verifier
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge 计算 PKCE S256 code_challenge
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,167 @@
+package oidc_test
+
+import (
+	"encoding/json"
+	"ffly-baisc/pkg/oidc"
+	"ffly-baisc/pkg/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const redirectURL = "http://localhost/api/v1/oidc/test/callback"
+
+func newProvider(t *testing.T, clientSecret string) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+
+	server := oidctest.NewServer("ffly", clientSecret)
+	t.Cleanup(server.Close)
+
+	return server, oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     "ffly",
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	}, server.Client())
+}
+
+// TestAuthorizationCodeFlow 元数据发现、授权码 + PKCE 换取令牌、校验 ID Token
+func TestAuthorizationCodeFlow(t *testing.T) {
+	for _, clientSecret := range []string{"secret", ""} {
+		t.Run("clientSecret="+clientSecret, func(t *testing.T) {
+			server, provider := newProvider(t, clientSecret)
+
+			metadata, err := provider.Metadata()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Issuer != server.Issuer() || metadata.TokenEndpoint != server.URL+"/token" {
+				t.Errorf("元数据 = %+v", metadata)
+			}
+
+			codeVerifier, _ := oidc.RandomString()
+			authURL, err := provider.AuthCodeURL("state-1", "nonce-1", oidc.CodeChallenge(codeVerifier))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(authURL, server.URL+"/authorize?") {
+				t.Errorf("授权地址 = %s", authURL)
+			}
+
+			code, state, err := server.Authorize(authURL, jwt.MapClaims{"sub": "u-1", "email": "a@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state != "state-1" {
+				t.Errorf("state = %s，期望 state-1", state)
+			}
+
+			tokenResponse, err := provider.Exchange(code, codeVerifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := provider.VerifyIDToken(tokenResponse.IDToken, "nonce-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims["sub"] != "u-1" || claims["email"] != "a@example.com" {
+				t.Errorf("claims = %v", claims)
+			}
+
+			// 授权码只能使用一次
+			if _, err := provider.Exchange(code, codeVerifier); err == nil {
+				t.Error("授权码重复使用应失败")
+			}
+		})
+	}
+}
+
+func TestExchangeErrors(t *testing.T) {
+	t.Run("code_verifier 不匹配", func(t *testing.T) {
+		server, provider := newProvider(t, "secret")
+		codeVerifier, _ := oidc.RandomString()
+		authURL, _ := provider.AuthCodeURL("s", "n", oidc.CodeChallenge(codeVerifier))
+		code, _, err := server.Authorize(authURL, jwt.MapClaims{"sub": "u-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := provider.Exchange(code, "wrong-verifier"); err == nil {
+			t.Error("PKCE 校验失败时应返回错误")
+		}
+	})
+
+	t.Run("客户端密钥错误", func(t *testing.T) {
+		server, _ := newProvider(t, "secret")
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       server.Issuer(),
+			ClientID:     "ffly",
+			ClientSecret: "wrong",
+			RedirectURL:  redirectURL,
+		}, server.Client())
+		codeVerifier, _ := oidc.RandomString()
+		authURL, _ := provider.AuthCodeURL("s", "n", oidc.CodeChallenge(codeVerifier))
+		code, _, err := server.Authorize(authURL, jwt.MapClaims{"sub": "u-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := provider.Exchange(code, codeVerifier); err == nil {
+			t.Error("客户端认证失败时应返回错误")
+		}
+	})
+}
+
+// TestVerifyIDTokenErrors 签名、issuer、audience、有效期、nonce、sub 任一不符合都拒绝
+func TestVerifyIDTokenErrors(t *testing.T) {
+	server, provider := newProvider(t, "secret")
+	other := oidctest.NewServer("ffly", "secret")
+	defer other.Close()
+
+	tests := []struct {
+		name   string
+		signer *oidctest.Server
+		claims jwt.MapClaims
+		nonce  string
+	}{
+		{"其他密钥签名", other, jwt.MapClaims{"iss": server.Issuer(), "sub": "u-1", "nonce": "n"}, "n"},
+		{"issuer 不匹配", server, jwt.MapClaims{"iss": other.Issuer(), "sub": "u-1", "nonce": "n"}, "n"},
+		{"audience 不匹配", server, jwt.MapClaims{"aud": "other-client", "sub": "u-1", "nonce": "n"}, "n"},
+		{"多个 audience 时 azp 不匹配", server, jwt.MapClaims{"aud": []string{"ffly", "other-client"}, "sub": "u-1", "nonce": "n"}, "n"},
+		{"已过期", server, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix(), "sub": "u-1", "nonce": "n"}, "n"},
+		{"nonce 不匹配", server, jwt.MapClaims{"sub": "u-1", "nonce": "n"}, "other"},
+		{"缺少 sub", server, jwt.MapClaims{"nonce": "n"}, "n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idToken, err := tt.signer.SignIDToken(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := provider.VerifyIDToken(idToken, tt.nonce); err == nil {
+				t.Error("ID Token 校验应失败")
+			}
+		})
+	}
+}
+
+func TestMetadataIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://evil.example.com",
+			"authorization_endpoint": "https://evil.example.com/authorize",
+			"token_endpoint":         "https://evil.example.com/token",
+			"jwks_uri":               "https://evil.example.com/jwks",
+		})
+	}))
+	defer server.Close()
+
+	provider := oidc.NewProvider(oidc.Config{Issuer: server.URL, ClientID: "ffly"}, server.Client())
+	if _, err := provider.Metadata(); err == nil {
+		t.Error("元数据 issuer 与配置不一致时应失败")
+	}
+}
@@ -0,0 +1,194 @@
+// Package oidctest 提供测试用的 OpenID Connect 身份提供方（元数据、JWKS、令牌端点），只用于测试
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "test-key"
+
+// Server 测试用的身份提供方，支持授权码 + PKCE，使用 RS256 签发 ID Token
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string // 为空时为公共客户端，令牌端点校验表单中的 client_id
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authRequest // 授权码 -> 授权请求
+}
+
+// authRequest 授权请求，授权码只能使用一次
+type authRequest struct {
+	redirectURI   string
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+// NewServer 启动身份提供方，使用完毕后需要调用 Close
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{ClientID: clientID, ClientSecret: clientSecret, key: key, codes: map[string]*authRequest{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleMetadata)
+	mux.HandleFunc("/jwks", s.handleJWKS)
+	mux.HandleFunc("/token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Issuer 颁发者地址
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// Authorize 模拟用户在身份提供方登录并同意授权，authURL 为客户端生成的授权地址
+// claims 为 ID Token 中的用户声明（sub、email 等），返回授权码和原样带回的 state
+func (s *Server) Authorize(authURL string, claims jwt.MapClaims) (string, string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := u.Query()
+	if query.Get("client_id") != s.ClientID {
+		return "", "", errors.New("client_id 不匹配")
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return "", "", errors.New("缺少 PKCE code_challenge")
+	}
+
+	idClaims := jwt.MapClaims{"nonce": query.Get("nonce")}
+	for k, v := range claims {
+		idClaims[k] = v
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = &authRequest{
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		claims:        idClaims,
+	}
+	s.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken 使用身份提供方的密钥签发 ID Token，默认填充 iss、aud、iat、exp，claims 中的同名声明优先
+func (s *Server) SignIDToken(claims jwt.MapClaims) (string, error) {
+	idClaims := jwt.MapClaims{
+		"iss": s.Issuer(),
+		"aud": s.ClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		idClaims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, idClaims)
+	token.Header["kid"] = keyID
+	return token.SignedString(s.key)
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   encode(s.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// 客户端认证：机密客户端使用 client_secret_basic，公共客户端只传 client_id
+	if s.ClientSecret != "" {
+		clientID, clientSecret, ok := r.BasicAuth()
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+		if !ok || clientID != s.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.ClientSecret)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+	} else if r.PostForm.Get("client_id") != s.ClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	request := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if request == nil || request.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != request.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := s.SignIDToken(request.claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// randomString 随机字符串，用于授权码和 Access Token
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
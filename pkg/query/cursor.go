@@ -0,0 +1,249 @@
+package query
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// cursorPayload 游标内容，base64 编码后返回给前端，前端只需原样传回
+type cursorPayload struct {
+	Sort   string   `json:"s"` // 生成游标时的排序，排序变化后游标失效
+	Values []string `json:"v"` // 排序字段的值
+	Prev   bool     `json:"p"` // 是否为上一页游标
+}
+
+// cursorState 游标分页状态，查询完成后据此生成 nextCursor / prevCursor
+type cursorState struct {
+	sort      []SortParam
+	fields    []*schema.Field // 排序字段，与 sort 一一对应
+	prev      bool            // 是否向前翻页
+	hasCursor bool            // 请求是否携带了游标（不是第一页）
+}
+
+// IsCursorMode 是否为游标分页，请求携带 cursor 参数（第一页为空值）时启用
+func IsCursorMode(c *gin.Context) bool {
+	_, ok := c.GetQuery("cursor")
+	return ok
+}
+
+// cursorQuery 构造游标分页（keyset）查询
+// 按排序字段的值定位，不使用 OFFSET，默认不统计总数（count=true 时统计）
+func cursorQuery(c *gin.Context, db *gorm.DB, query *gorm.DB, model any, sortParams []SortParam) (*gorm.DB, *Pagination, error) {
+	if len(sortParams) == 0 {
+		return nil, nil, paramErrorf("该列表不支持游标分页")
+	}
+
+	names := make([]string, 0, len(sortParams))
+	for _, sortParam := range sortParams {
+		names = append(names, sortParam.Field)
+	}
+	fieldMap, err := lookUpFields(db, model, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state := &cursorState{sort: sortParams}
+	for _, sortParam := range sortParams {
+		field := fieldMap[sortParam.Field]
+		// 可为空的字段无法比较大小，不能作为游标
+		if field.FieldType.Kind() == reflect.Ptr {
+			return nil, nil, paramErrorf("游标分页不支持按可为空的字段 %s 排序", sortParam.Field)
+		}
+		state.fields = append(state.fields, field)
+	}
+
+	p := &Pagination{Size: GetSize(c), cursor: state}
+
+	// 统计总数（可选），在加入游标条件前统计
+	if c.Query("count") == "true" {
+		p.Total = new(int64)
+		if err := query.Count(p.Total).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		payload, err := decodeCursor(token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if payload.Sort != formatSort(sortParams) || len(payload.Values) != len(sortParams) {
+			return nil, nil, paramErrorf("游标与当前排序不匹配，请重新查询")
+		}
+
+		values := make([]any, 0, len(payload.Values))
+		for i, val := range payload.Values {
+			value, err := convertValue(state.fields[i], val)
+			if err != nil {
+				return nil, nil, paramErrorf("无效的游标")
+			}
+			values = append(values, value)
+		}
+
+		state.prev = payload.Prev
+		state.hasCursor = true
+		query = query.Where(keysetCondition(sortParams, values, payload.Prev))
+	}
+
+	// 向前翻页时反向排序，查询后再把结果倒过来
+	orderParams := sortParams
+	if state.prev {
+		orderParams = make([]SortParam, 0, len(sortParams))
+		for _, sortParam := range sortParams {
+			orderParams = append(orderParams, SortParam{Field: sortParam.Field, Desc: !sortParam.Desc})
+		}
+	}
+	query = BuildSort(query, orderParams)
+
+	// 多查一条用于判断是否还有数据
+	query = query.Limit(p.Size + 1)
+
+	return query, p, nil
+}
+
+// keysetCondition 构造游标条件：(a > x) OR (a = x AND b > y) ...，降序字段使用 <，向前翻页时方向相反
+func keysetCondition(sortParams []SortParam, values []any, prev bool) clause.Expression {
+	terms := make([]clause.Expression, 0, len(sortParams))
+	for i, sortParam := range sortParams {
+		expressions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			expressions = append(expressions, clause.Eq{Column: sortColumn(sortParams[j]), Value: values[j]})
+		}
+		if sortParam.Desc != prev {
+			expressions = append(expressions, clause.Lt{Column: sortColumn(sortParam), Value: values[i]})
+		} else {
+			expressions = append(expressions, clause.Gt{Column: sortColumn(sortParam), Value: values[i]})
+		}
+		terms = append(terms, clause.And(expressions...))
+	}
+
+	// 只有一个条件时直接返回，避免 gorm 把只有一个条件的 OR 组与前面的条件用 OR 连接
+	if len(terms) == 1 {
+		return terms[0]
+	}
+
+	return clause.Or(terms...)
+}
+
+func sortColumn(sortParam SortParam) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: sortParam.Field}
+}
+
+// setCursors 根据查询结果生成游标，去掉多查的一条，向前翻页时把结果倒过来
+func setCursors[T any](p *Pagination, result *[]*T) error {
+	state := p.cursor
+	rows := *result
+
+	hasMore := len(rows) > p.Size
+	if hasMore {
+		rows = rows[:p.Size]
+	}
+	if state.prev {
+		slices.Reverse(rows)
+	}
+	*result = rows
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// 向后翻页：多查到数据说明还有下一页，携带了游标说明有上一页
+	// 向前翻页：多查到数据说明还有上一页，一定有下一页（从下一页翻回来的）
+	hasNext, hasPrev := hasMore, state.hasCursor
+	if state.prev {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var err error
+	if hasNext {
+		if p.NextCursor, err = state.encode(rows[len(rows)-1], false); err != nil {
+			return err
+		}
+	}
+	if hasPrev {
+		if p.PrevCursor, err = state.encode(rows[0], true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encode 使用某一行的排序字段值生成游标
+func (state *cursorState) encode(row any, prev bool) (string, error) {
+	payload := cursorPayload{Sort: formatSort(state.sort), Prev: prev}
+	for _, field := range state.fields {
+		value, _ := field.ValueOf(context.Background(), reflect.ValueOf(row))
+		payload.Values = append(payload.Values, formatValue(value))
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(token string) (*cursorPayload, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, paramErrorf("无效的游标")
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, paramErrorf("无效的游标")
+	}
+
+	return &payload, nil
+}
+
+// formatSort 将排序字段格式化为 sort 参数的格式
+func formatSort(sortParams []SortParam) string {
+	items := make([]string, 0, len(sortParams))
+	for _, sortParam := range sortParams {
+		if sortParam.Desc {
+			items = append(items, "-"+sortParam.Field)
+		} else {
+			items = append(items, sortParam.Field)
+		}
+	}
+
+	return strings.Join(items, ",")
+}
+
+// formatValue 将字段值格式化为字符串，解析游标时再按字段类型转换回来
+// 不使用 fmt.Sprint，避免 types.Status 等实现了 String 方法的类型被格式化为名称
+func formatValue(value any) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	}
+
+	return ""
+}
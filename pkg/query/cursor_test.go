@@ -0,0 +1,138 @@
+package query_test
+
+import (
+	"database/sql/driver"
+	"ffly-baisc/pkg/query"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+// userRows 按 id, username 构造查询结果
+func userRows(rows ...[2]driver.Value) *sqlmock.Rows {
+	result := sqlmock.NewRows([]string{"id", "username"})
+	for _, row := range rows {
+		result.AddRow(row[0], row[1])
+	}
+
+	return result
+}
+
+func listUsers(t *testing.T, db *gorm.DB, rawQuery string) ([]*testUser, *query.Pagination) {
+	t.Helper()
+
+	users, p, err := query.GetQueryData[testUser](db, newContext(rawQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *users, p
+}
+
+func userIDs(users []*testUser) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
+
+// TestCursorPagination 第一页、下一页、再翻回上一页
+func TestCursorPagination(t *testing.T) {
+	db, mock := mockDB(t)
+
+	// 第一页：多查一条判断是否还有下一页
+	mock.ExpectQuery("SELECT * FROM `users` ORDER BY `users`.`username`,`users`.`id` LIMIT ?").
+		WithArgs(3).
+		WillReturnRows(userRows([2]driver.Value{1, "a"}, [2]driver.Value{2, "b"}, [2]driver.Value{3, "c"}))
+	users, p := listUsers(t, db, "cursor=&size=2&sort=username")
+	if ids := userIDs(users); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("第一页 = %v，期望 [1 2]", ids)
+	}
+	if p.NextCursor == "" || p.PrevCursor != "" || p.Total != nil {
+		t.Fatalf("第一页分页信息 = %+v", p)
+	}
+
+	// 下一页：从第一页最后一条 (b, 2) 之后开始
+	mock.ExpectQuery("SELECT * FROM `users` WHERE (`users`.`username` > ? OR (`users`.`username` = ? AND `users`.`id` > ?)) ORDER BY `users`.`username`,`users`.`id` LIMIT ?").
+		WithArgs("b", "b", uint64(2), 3).
+		WillReturnRows(userRows([2]driver.Value{3, "c"}))
+	users, p = listUsers(t, db, "cursor="+p.NextCursor+"&size=2&sort=username")
+	if ids := userIDs(users); len(ids) != 1 || ids[0] != 3 {
+		t.Fatalf("第二页 = %v，期望 [3]", ids)
+	}
+	if p.NextCursor != "" || p.PrevCursor == "" {
+		t.Fatalf("第二页分页信息 = %+v", p)
+	}
+
+	// 上一页：从 (c, 3) 之前反向查询，结果再倒过来
+	mock.ExpectQuery("SELECT * FROM `users` WHERE (`users`.`username` < ? OR (`users`.`username` = ? AND `users`.`id` < ?)) ORDER BY `users`.`username` DESC,`users`.`id` DESC LIMIT ?").
+		WithArgs("c", "c", uint64(3), 3).
+		WillReturnRows(userRows([2]driver.Value{2, "b"}, [2]driver.Value{1, "a"}))
+	users, p = listUsers(t, db, "cursor="+p.PrevCursor+"&size=2&sort=username")
+	if ids := userIDs(users); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("上一页 = %v，期望 [1 2]", ids)
+	}
+	if p.NextCursor == "" || p.PrevCursor != "" {
+		t.Fatalf("上一页分页信息 = %+v", p)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCursorPaginationCount count=true 时在加入游标条件前统计总数
+func TestCursorPaginationCount(t *testing.T) {
+	db, mock := mockDB(t)
+
+	mock.ExpectQuery("SELECT count(*) FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT * FROM `users` ORDER BY `users`.`id` DESC LIMIT ?").
+		WithArgs(11).
+		WillReturnRows(userRows([2]driver.Value{5, "e"}))
+
+	_, p := listUsers(t, db, "cursor=&count=true&sort=-id")
+	if p.Total == nil || *p.Total != 5 {
+		t.Errorf("Total = %v，期望 5", p.Total)
+	}
+	if p.NextCursor != "" || p.PrevCursor != "" {
+		t.Errorf("只有一页时不应返回游标: %+v", p)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCursorParamErrors(t *testing.T) {
+	db, mock := mockDB(t)
+
+	// 生成一个按 username 排序的游标
+	mock.ExpectQuery("SELECT * FROM `users` ORDER BY `users`.`username`,`users`.`id` LIMIT ?").
+		WithArgs(2).
+		WillReturnRows(userRows([2]driver.Value{1, "a"}, [2]driver.Value{2, "b"}))
+	_, p := listUsers(t, db, "cursor=&size=1&sort=username")
+	if p.NextCursor == "" {
+		t.Fatal("应返回下一页游标")
+	}
+
+	tests := []struct {
+		name     string
+		model    any
+		rawQuery string
+	}{
+		{"排序变化后游标失效", &testUser{}, "cursor=" + p.NextCursor + "&sort=-username"},
+		{"无效的游标", &testUser{}, "cursor=not-a-cursor&sort=username"},
+		{"可为空的排序字段", &testUser{}, "cursor=&sort=email"},
+		{"模型不支持排序", &testLog{}, "cursor="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := query.GetQuerySQL(newContext(tt.rawQuery), db, tt.model)
+			assertParamError(t, err)
+		})
+	}
+}
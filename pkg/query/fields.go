@@ -0,0 +1,99 @@
+package query
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FieldQuerier 定义可查询字段的接口，未实现该接口的模型不允许使用 fields 参数
+type FieldQuerier interface {
+	SelectQueryFields() []string // 返回允许查询的字段（数据库列名）
+}
+
+// ExpandQuerier 定义可展开关联的接口，未实现该接口的模型不允许使用 expand 参数
+type ExpandQuerier interface {
+	ExpandQueryFields() []string // 返回允许展开的关联，如 roles
+}
+
+// splitList 解析逗号分隔的参数，去掉空白和空值
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// GetFields 获取 fields 参数（如 fields=id,username,email），未指定时使用列表视图的查询字段
+func GetFields(c *gin.Context, db *gorm.DB, model any, view *View) ([]string, error) {
+	names := splitList(c.Query("fields"))
+	if len(names) == 0 && view != nil {
+		names = view.Fields
+	}
+
+	return ResolveFields(db, model, names)
+}
+
+// ResolveFields 校验查询字段，字段必须在模型的查询字段白名单中
+// 未指定时返回 nil，总是包含主键，用于展开关联和前端标识行
+func ResolveFields(db *gorm.DB, model any, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	fieldQuerier, ok := model.(FieldQuerier)
+	if !ok {
+		return nil, paramErrorf("该列表不支持指定查询字段")
+	}
+
+	fields, err := lookUpFields(db, model, fieldQuerier.SelectQueryFields())
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{"id"}
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return nil, paramErrorf("不支持查询字段 %s", name)
+		}
+		if !slices.Contains(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+
+	return columns, nil
+}
+
+// GetExpand 获取 expand 参数（如 expand=roles），关联必须在模型的展开白名单中
+func GetExpand(c *gin.Context, model any) ([]string, error) {
+	names := splitList(c.Query("expand"))
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	expandQuerier, ok := model.(ExpandQuerier)
+	if !ok {
+		return nil, paramErrorf("该列表不支持展开关联数据")
+	}
+
+	allowed := expandQuerier.ExpandQueryFields()
+	for _, name := range names {
+		if !slices.Contains(allowed, name) {
+			return nil, paramErrorf("不支持展开关联 %s", name)
+		}
+	}
+
+	return names, nil
+}
+
+// IsExpanded 是否需要展开某个关联，服务层查询列表后据此按需加载关联数据
+// expand 参数已在 GetQuerySQL 中校验
+func IsExpanded(c *gin.Context, name string) bool {
+	return slices.Contains(splitList(c.Query("expand")), name)
+}
@@ -0,0 +1,148 @@
+package query_test
+
+import (
+	"ffly-baisc/pkg/query"
+	"reflect"
+	"testing"
+)
+
+// TestResolveFields 总是包含主键并去重，字段必须在白名单中
+func TestResolveFields(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{names: nil, want: nil},
+		{names: []string{"username", "email"}, want: []string{"id", "username", "email"}},
+		{names: []string{"username", "id", "username"}, want: []string{"id", "username"}},
+	}
+	for _, tt := range tests {
+		got, err := query.ResolveFields(dryDB(t), &testUser{}, tt.names)
+		if err != nil {
+			t.Errorf("ResolveFields(%v) err = %v", tt.names, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ResolveFields(%v) = %v，期望 %v", tt.names, got, tt.want)
+		}
+	}
+
+	t.Run("不在白名单中的字段", func(t *testing.T) {
+		_, err := query.ResolveFields(dryDB(t), &testUser{}, []string{"username", "password"})
+		assertParamError(t, err)
+	})
+
+	t.Run("模型不支持指定查询字段", func(t *testing.T) {
+		_, err := query.ResolveFields(dryDB(t), &testLog{}, []string{"path"})
+		assertParamError(t, err)
+	})
+}
+
+// TestGetFields fields 参数优先于列表视图的查询字段
+func TestGetFields(t *testing.T) {
+	view := &query.View{Fields: []string{"username"}}
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		view     *query.View
+		want     []string
+	}{
+		{"未指定", "", nil, nil},
+		{"使用视图字段", "", view, []string{"id", "username"}},
+		{"fields 参数", "fields=email,+status", view, []string{"id", "email", "status"}},
+		{"忽略空值", "fields=,%20email%20,", nil, []string{"id", "email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.GetFields(newContext(tt.rawQuery), dryDB(t), &testUser{}, tt.view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetFields = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		model    any
+		want     []string
+		wantErr  bool
+	}{
+		{"未指定", "", &testUser{}, nil, false},
+		{"允许展开", "expand=roles", &testUser{}, []string{"roles"}, false},
+		{"不在白名单中的关联", "expand=roles,permissions", &testUser{}, nil, true},
+		{"模型不支持展开", "expand=roles", &testLog{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.GetExpand(newContext(tt.rawQuery), tt.model)
+			if tt.wantErr {
+				assertParamError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetExpand = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExpanded(t *testing.T) {
+	c := newContext("expand=roles,%20permissions")
+	if !query.IsExpanded(c, "roles") || !query.IsExpanded(c, "permissions") {
+		t.Error("roles 和 permissions 应被展开")
+	}
+	if query.IsExpanded(c, "logs") || query.IsExpanded(newContext(""), "roles") {
+		t.Error("未指定的关联不应被展开")
+	}
+}
+
+// TestGetQuerySQLFields fields 参数优先于 simple，游标分页时补充排序字段
+func TestGetQuerySQLFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		want     string
+	}{
+		{
+			"fields 优先于 simple",
+			"fields=username&simple=true&sort=username&complete=true",
+			"SELECT `id`,`username` FROM `users` ORDER BY `users`.`username`,`users`.`id`",
+		},
+		{
+			"游标分页补充排序字段",
+			"fields=username&cursor=&sort=-status&size=10",
+			"SELECT `id`,`username`,`status` FROM `users` ORDER BY `users`.`status` DESC,`users`.`id` DESC LIMIT ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, _, err := query.GetQuerySQL(newContext(tt.rawQuery), dryDB(t), &testUser{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql, _ := findSQL(tx); sql != tt.want {
+				t.Errorf("SQL = %s\n期望 %s", sql, tt.want)
+			}
+		})
+	}
+
+	t.Run("不在白名单中的字段", func(t *testing.T) {
+		_, _, err := query.GetQuerySQL(newContext("fields=password"), dryDB(t), &testUser{})
+		assertParamError(t, err)
+	})
+
+	t.Run("不在白名单中的关联", func(t *testing.T) {
+		_, _, err := query.GetQuerySQL(newContext("expand=logs"), dryDB(t), &testUser{})
+		assertParamError(t, err)
+	})
+}
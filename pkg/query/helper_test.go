@@ -0,0 +1,122 @@
+package query_test
+
+import (
+	"errors"
+	"ffly-baisc/pkg/query"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testUser 测试模型，覆盖字符串、可为空、整数、布尔、浮点数和时间字段
+type testUser struct {
+	ID        uint
+	Username  string
+	Email     *string
+	Status    int8
+	Enabled   bool
+	Score     float64
+	Password  string
+	CreatedAt time.Time
+}
+
+func (u *testUser) TableName() string {
+	return "users"
+}
+
+func (u *testUser) FilterQueryFields() []string {
+	return []string{"id", "username", "email", "status", "enabled", "score", "created_at"}
+}
+
+func (u *testUser) SortQueryFields() []string {
+	return []string{"id", "username", "email", "status", "created_at"}
+}
+
+func (u *testUser) DefaultSort() string {
+	return "-created_at"
+}
+
+func (u *testUser) SelectQueryFields() []string {
+	return []string{"id", "username", "email", "status", "created_at"}
+}
+
+func (u *testUser) ExpandQueryFields() []string {
+	return []string{"roles"}
+}
+
+// testLog 没有实现任何白名单接口的模型
+type testLog struct {
+	ID   uint
+	Path string
+}
+
+func (l *testLog) TableName() string {
+	return "logs"
+}
+
+// dryDB 不连接数据库，只生成 SQL
+func dryDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// mockDB 使用 sqlmock 的数据库，按完整 SQL 匹配
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, mock
+}
+
+// newContext 构造 GET 请求的上下文，rawQuery 为 URL 查询字符串
+func newContext(rawQuery string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+rawQuery, nil)
+
+	return c
+}
+
+// findSQL 生成查询语句的 SQL 和参数
+func findSQL(tx *gorm.DB) (string, string) {
+	stmt := tx.Find(&[]*testUser{}).Statement
+	return stmt.SQL.String(), fmt.Sprint(stmt.Vars)
+}
+
+// assertParamError 错误必须是 ParamError（返回 400）
+func assertParamError(t *testing.T, err error) {
+	t.Helper()
+
+	var paramErr *query.ParamError
+	if !errors.As(err, &paramErr) {
+		t.Errorf("err = %v (%T)，期望 *query.ParamError", err, err)
+	}
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// joinRow 关联表的一行，owner 为查询方，target 为被关联方
+type joinRow struct {
+	OwnerID  uint
+	TargetID uint
+}
+
+// LoadJoinIDs 批量加载多对多关联的ID，一次查询关联表
+// 例如 LoadJoinIDs(db, &model.RolePermission{}, "role_id", "permission_id", roleIDs) 返回 角色ID -> 权限ID列表
+func LoadJoinIDs(db *gorm.DB, joinModel any, ownerColumn, targetColumn string, ownerIDs []uint) (map[uint][]uint, error) {
+	result := make(map[uint][]uint, len(ownerIDs))
+	if len(ownerIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []joinRow
+	if err := db.Model(joinModel).
+		Select("? AS owner_id, ? AS target_id", clause.Column{Name: ownerColumn}, clause.Column{Name: targetColumn}).
+		Where(clause.IN{Column: clause.Column{Name: ownerColumn}, Values: uintValues(ownerIDs)}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}}).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询关联数据失败: %w", err)
+	}
+
+	for _, row := range rows {
+		result[row.OwnerID] = append(result[row.OwnerID], row.TargetID)
+	}
+
+	return result, nil
+}
+
+// LoadJoin 批量加载多对多关联数据，先查询关联表，再按ID批量查询被关联的数据，共两次查询，与数量无关
+// 例如 LoadJoin[model.Role](db, &model.UserRole{}, "user_id", "role_id", userIDs, ...) 返回 用户ID -> 角色列表
+func LoadJoin[T any](db *gorm.DB, joinModel any, ownerColumn, targetColumn string, ownerIDs []uint, idOf func(*T) uint) (map[uint][]*T, error) {
+	joinIDs, err := LoadJoinIDs(db, joinModel, ownerColumn, targetColumn, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 去重后批量查询
+	seen := make(map[uint]bool)
+	var targetIDs []uint
+	for _, ids := range joinIDs {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				targetIDs = append(targetIDs, id)
+			}
+		}
+	}
+
+	result := make(map[uint][]*T, len(ownerIDs))
+	if len(targetIDs) == 0 {
+		return result, nil
+	}
+
+	var targets []*T
+	if err := db.Model(new(T)).Where(clause.IN{Column: clause.PrimaryColumn, Values: uintValues(targetIDs)}).Find(&targets).Error; err != nil {
+		return nil, fmt.Errorf("查询关联数据失败: %w", err)
+	}
+
+	targetMap := make(map[uint]*T, len(targets))
+	for _, target := range targets {
+		targetMap[idOf(target)] = target
+	}
+
+	// 按被关联数据的主键顺序返回，已删除的数据会被忽略
+	for ownerID, ids := range joinIDs {
+		ids = slices.Clone(ids)
+		slices.Sort(ids)
+		for _, id := range slices.Compact(ids) {
+			if target, ok := targetMap[id]; ok {
+				result[ownerID] = append(result[ownerID], target)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func uintValues(ids []uint) []any {
+	values := make([]any, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id)
+	}
+
+	return values
+}
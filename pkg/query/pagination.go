@@ -8,9 +8,13 @@ import (
 )
 
 type Pagination struct {
-	Page  int    `json:"page"`
-	Size  int    `json:"size"`
-	Total *int64 `json:"total"`
+	Page       int    `json:"page"`
+	Size       int    `json:"size"`
+	Total      *int64 `json:"total"`                // 游标分页默认不统计总数，为 nil
+	NextCursor string `json:"nextCursor,omitempty"` // 游标分页的下一页游标，为空时没有下一页
+	PrevCursor string `json:"prevCursor,omitempty"` // 游标分页的上一页游标，为空时没有上一页
+
+	cursor *cursorState // 游标分页状态，普通分页为 nil
 }
 
 // GetPage 获取分页页码
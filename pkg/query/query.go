@@ -11,8 +11,14 @@ type Query struct {
 	Page     int           `json:"page"`     // 页码
 	Size     int           `json:"size"`     // 每页显示的数量
 	Params   []SearchParam `json:"params"`   // 查询参数
+	Sort     string        `json:"sort"`     // 排序字段，逗号分隔，字段前加 - 表示降序
 	Simple   bool          `json:"simple"`   // 是否为简单查询(仅返回id，name，value字段)
 	Complete bool          `json:"complete"` // 是否为完全查询
+	Cursor   string        `json:"cursor"`   // 游标分页的游标，第一页传空值
+	Count    bool          `json:"count"`    // 游标分页时是否统计总数
+	Fields   []string      `json:"fields"`   // 查询字段，逗号分隔
+	Expand   []string      `json:"expand"`   // 展开的关联，逗号分隔
+	View     uint          `json:"view"`     // 保存的列表视图ID
 }
 
 // SimpleQuerier 定义简单查询的接口
@@ -24,8 +30,14 @@ var simpleCommonFields = []string{"id", "name"}
 
 // GetQuerySQL 获取查询SQL以及分页信息
 func GetQuerySQL[T any](c *gin.Context, db *gorm.DB, model T) (*gorm.DB, *Pagination, error) {
+	// 获取列表视图
+	view, err := GetView(c, db, model)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// 获取查询语句
-	query, err := GetQuery(c, db)
+	query, err := GetQuery(c, db, model, view)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -36,8 +48,35 @@ func GetQuerySQL[T any](c *gin.Context, db *gorm.DB, model T) (*gorm.DB, *Pagina
 		return nil, nil, query.Error
 	}
 
-	// 判断是否为简单查询
-	if c.DefaultQuery("simple", "false") == "true" {
+	// 获取排序字段
+	sortParams, err := GetSort(c, db, model, view)
+	if err != nil {
+		return nil, nil, err
+	}
+	cursorMode := IsCursorMode(c)
+
+	// 校验展开的关联，关联数据由服务层按需加载
+	if _, err := GetExpand(c, model); err != nil {
+		return nil, nil, err
+	}
+
+	// 获取查询字段
+	fields, err := GetFields(c, db, model, view)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(fields) > 0 {
+		// 指定了查询字段，优先于简单查询
+		if cursorMode {
+			fields = withSortFields(fields, sortParams)
+		}
+
+		query = query.Select(fields)
+		if query.Error != nil {
+			return nil, nil, query.Error
+		}
+	} else if c.DefaultQuery("simple", "false") == "true" {
 		// 简单查询 (仅返回id，name，value字段)
 
 		// 如果没有指定字段，则使用默认字段(id, name)
@@ -52,12 +91,25 @@ func GetQuerySQL[T any](c *gin.Context, db *gorm.DB, model T) (*gorm.DB, *Pagina
 			}
 		}
 
+		// 游标分页需要排序字段的值生成游标
+		if cursorMode {
+			fields = withSortFields(fields, sortParams)
+		}
+
 		query = query.Select(fields)
 		if query.Error != nil {
 			return nil, nil, query.Error
 		}
 	}
 
+	// 游标分页
+	if cursorMode {
+		return cursorQuery(c, db, query, model, sortParams)
+	}
+
+	// 排序，分页和完全查询都按同样的顺序返回
+	query = BuildSort(query, sortParams)
+
 	var p *Pagination
 	// 判断是否为完全查询 (是否分页)
 	if c.DefaultQuery("complete", "false") != "true" {
@@ -107,5 +159,12 @@ func GetQueryData[T any](db *gorm.DB, c *gin.Context) (*[]*T, *Pagination, error
 		return nil, nil, err
 	}
 
+	// 游标分页：生成上一页、下一页游标
+	if p != nil && p.cursor != nil {
+		if err := setCursors(p, result); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return result, p, nil
 }
@@ -1,84 +1,552 @@
 package query
 
 import (
+	"bytes"
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// SearchParamsSchema params 参数的 JSON Schema，前端可以据此校验查询条件
+//
+//go:embed search_params.schema.json
+var SearchParamsSchema []byte
+
 // SearchParam 定义查询参数结构体
+// 单个条件使用 param + sign + val，条件组使用 logic + params，两者不能同时出现
 type SearchParam struct {
-	Param string `json:"param"`
-	Sign  string `json:"sign"` // 大写 EQ, NEQ, LK, IN, GT, GTE, LT, LTE,
-	Val   string `json:"val"`
+	Param  string        `json:"param,omitempty"`  // 字段名（数据库列名），必须在模型的过滤字段白名单中；关联字段使用 关联名.字段名
+	Sign   string        `json:"sign,omitempty"`   // 大写 EQ, NEQ, LK, NLK, SW, EW, IN, NIN, GT, GTE, LT, LTE, BT, NULL, NNULL
+	Val    SearchValue   `json:"val,omitempty"`    // 值，按字段类型转换，IN / NIN / BT 使用数组（兼容逗号分隔的字符串）
+	CI     bool          `json:"ci,omitempty"`     // 忽略大小写，只对字符串字段有效
+	Logic  string        `json:"logic,omitempty"`  // 条件组的逻辑关系 AND / OR，默认 AND
+	Params []SearchParam `json:"params,omitempty"` // 条件组的子条件
+}
+
+// SearchValue 查询值，JSON 中可以是字符串、数字、布尔值或它们组成的数组
+type SearchValue []string
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，数字和布尔值统一转为字符串，再按字段类型转换
+func (v *SearchValue) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // 避免大整数丢失精度
+	var raw any
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case nil:
+		*v = nil
+	case []any:
+		values := make(SearchValue, 0, len(value))
+		for _, item := range value {
+			s, err := scalarString(item)
+			if err != nil {
+				return err
+			}
+			values = append(values, s)
+		}
+		*v = values
+	default:
+		s, err := scalarString(value)
+		if err != nil {
+			return err
+		}
+		*v = SearchValue{s}
+	}
+
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，单个值输出为字符串
+func (v SearchValue) MarshalJSON() ([]byte, error) {
+	if len(v) == 1 {
+		return json.Marshal(v[0])
+	}
+
+	return json.Marshal([]string(v))
+}
+
+func scalarString(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+
+	return "", fmt.Errorf("查询值只能是字符串、数字、布尔值或它们组成的数组")
+}
+
+// FilterQuerier 定义可过滤字段的接口，未实现该接口的模型不允许使用 params 过滤
+type FilterQuerier interface {
+	FilterQueryFields() []string // 返回允许过滤的字段（数据库列名）
+}
+
+// RelationQuerier 定义可按关联数据过滤的接口，params 中使用 关联名.字段名（如 roles.code）
+type RelationQuerier interface {
+	FilterQueryRelations() map[string]Relation // 返回允许过滤的关联，key 为关联名
+}
+
+// Relation 通过关联表实现的多对多关联
+type Relation struct {
+	Model        any    // 关联模型，如 &model.Role{}，字段按其 FilterQueryFields 白名单校验
+	JoinModel    any    // 关联表模型，如 &model.UserRole{}
+	OwnerColumn  string // 关联表中查询方的列，如 user_id
+	TargetColumn string // 关联表中关联模型的列，如 role_id
+}
+
+// ParamError 查询参数错误（未知字段、非法的值等），调用方应返回 400
+type ParamError struct {
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return e.Message
+}
+
+func paramErrorf(format string, args ...any) error {
+	return &ParamError{Message: fmt.Sprintf(format, args...)}
+}
+
+const (
+	maxSearchDepth      = 5  // 条件组最大嵌套层数
+	maxSearchConditions = 50 // 最多条件数量
+)
+
+// searchSigns 支持的查询条件
+var searchSigns = map[string]bool{
+	"EQ": true, "NEQ": true, "LK": true, "NLK": true, "SW": true, "EW": true, "IN": true, "NIN": true,
+	"GT": true, "GTE": true, "LT": true, "LTE": true, "BT": true, "NULL": true, "NNULL": true,
+}
+
+// timeLayouts 时间字段支持的格式
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// likeEscaper 转义 LIKE 中的通配符，模糊查询只按字面值匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike 转义 LIKE 中的通配符，用于业务层自行拼接模糊查询
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// BuildQuery 构造查询语句，顶层的多个条件为 AND 关系
+// 字段必须在模型的过滤字段白名单中，值按字段的 Go 类型转换，列名不会拼接到 SQL 中
+func BuildQuery(db *gorm.DB, model any, searchParamSlice []SearchParam) (*gorm.DB, error) {
+	if len(searchParamSlice) == 0 {
+		return db, nil
+	}
+
+	fields, err := filterFields(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &searchBuilder{db: db, model: model, fields: fields}
+	expression, err := builder.group("AND", searchParamSlice, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Where(expression), nil
+}
+
+// searchBuilder 将查询参数转换为 gorm 条件表达式
+type searchBuilder struct {
+	db             *gorm.DB
+	model          any
+	fields         map[string]*schema.Field
+	relationFields map[string]map[string]*schema.Field // 关联名 -> 关联模型允许过滤的字段
+	conditions     int
+}
+
+// group 构造条件组
+func (b *searchBuilder) group(logic string, params []SearchParam, depth int) (clause.Expression, error) {
+	if depth > maxSearchDepth {
+		return nil, paramErrorf("条件组最多嵌套 %d 层", maxSearchDepth)
+	}
+	if len(params) == 0 {
+		return nil, paramErrorf("条件组不能为空")
+	}
+
+	expressions := make([]clause.Expression, 0, len(params))
+	for _, s := range params {
+		var expression clause.Expression
+		var err error
+		if len(s.Params) > 0 || s.Logic != "" {
+			if s.Param != "" || s.Sign != "" {
+				return nil, paramErrorf("条件组不能同时包含 param 和 sign")
+			}
+			expression, err = b.group(s.Logic, s.Params, depth+1)
+		} else {
+			expression, err = b.condition(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+
+	logic = strings.ToUpper(logic)
+	if logic != "" && logic != "AND" && logic != "OR" {
+		return nil, paramErrorf("不支持的逻辑关系 %s，只能是 AND 或 OR", logic)
+	}
+	// 只有一个条件时直接返回，gorm 会把只有一个条件的 OR 组与前面的条件用 OR 连接
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+	if logic == "OR" {
+		return clause.Or(expressions...), nil
+	}
+
+	return clause.And(expressions...), nil
+}
+
+// condition 构造单个条件
+func (b *searchBuilder) condition(s SearchParam) (clause.Expression, error) {
+	b.conditions++
+	if b.conditions > maxSearchConditions {
+		return nil, paramErrorf("查询条件不能超过 %d 个", maxSearchConditions)
+	}
+
+	if field, ok := b.fields[s.Param]; ok {
+		return b.compare(field, s)
+	}
+
+	// 关联字段，如 roles.code
+	if name, param, ok := strings.Cut(s.Param, "."); ok {
+		return b.relationCondition(name, param, s)
+	}
+
+	return nil, paramErrorf("不支持按字段 %s 查询", s.Param)
 }
 
-// BuildQuery 构造查询语句
-func BuildQuery(db *gorm.DB, searchParamSlice []SearchParam) *gorm.DB {
-	for _, s := range searchParamSlice {
-		sign := strings.ToUpper(s.Sign)
+// relationCondition 构造关联字段条件：任意一条关联数据满足条件即可，通过关联表子查询实现
+// 例如 roles.code EQ ops 转换为 users.id IN (SELECT user_id FROM user_roles WHERE role_id IN (SELECT id FROM roles WHERE code = 'ops'))
+func (b *searchBuilder) relationCondition(name, param string, s SearchParam) (clause.Expression, error) {
+	relationQuerier, ok := b.model.(RelationQuerier)
+	if !ok {
+		return nil, paramErrorf("不支持按字段 %s 查询", s.Param)
+	}
+	relation, ok := relationQuerier.FilterQueryRelations()[name]
+	if !ok {
+		return nil, paramErrorf("不支持按字段 %s 查询", s.Param)
+	}
+
+	// 关联模型的字段使用关联模型的过滤字段白名单
+	if b.relationFields == nil {
+		b.relationFields = make(map[string]map[string]*schema.Field)
+	}
+	fields, ok := b.relationFields[name]
+	if !ok {
+		var err error
+		if fields, err = filterFields(b.db, relation.Model); err != nil {
+			return nil, err
+		}
+		b.relationFields[name] = fields
+	}
+	field, ok := fields[param]
+	if !ok {
+		return nil, paramErrorf("不支持按字段 %s 查询", s.Param)
+	}
 
+	expression, err := b.compare(field, s)
+	if err != nil {
+		return nil, err
+	}
+
+	session := b.db.Session(&gorm.Session{NewDB: true})
+	targets := session.Model(relation.Model).Select("?", clause.PrimaryColumn).Where(expression)
+	owners := session.Model(relation.JoinModel).Select("?", clause.Column{Name: relation.OwnerColumn}).
+		Where(clause.Expr{SQL: "? IN (?)", Vars: []any{clause.Column{Name: relation.TargetColumn}, targets}})
+
+	return clause.Expr{SQL: "? IN (?)", Vars: []any{clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}, owners}}, nil
+}
+
+// compare 构造字段比较条件，列名使用当前查询的表
+func (b *searchBuilder) compare(field *schema.Field, s SearchParam) (clause.Expression, error) {
+	sign := strings.ToUpper(s.Sign)
+	if !searchSigns[sign] {
+		return nil, paramErrorf("不支持的查询条件 %s", s.Sign)
+	}
+	isString := field.IndirectFieldType.Kind() == reflect.String
+
+	var column any = clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	if s.CI {
+		// 忽略大小写，列和值都转为小写比较
+		if !isString {
+			return nil, paramErrorf("字段 %s 不支持忽略大小写", s.Param)
+		}
+		column = clause.Expr{SQL: "LOWER(?)", Vars: []any{column}}
+		lower := make(SearchValue, len(s.Val))
+		for i, val := range s.Val {
+			lower[i] = strings.ToLower(val)
+		}
+		s.Val = lower
+	}
+
+	switch sign {
+	case "NULL":
+		// 查询为空
+		return clause.Expr{SQL: "? IS NULL", Vars: []any{column}}, nil
+	case "NNULL":
+		// 查询不为空
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []any{column}}, nil
+	case "IN", "NIN":
+		// 查询某个值在（不在）某个范围内
+		values, err := b.values(field, splitValues(s.Val))
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, paramErrorf("字段 %s 的 %s 条件至少需要一个值", s.Param, sign)
+		}
+		if sign == "NIN" {
+			return clause.Not(clause.IN{Column: column, Values: values}), nil
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case "BT":
+		// 查询在某个区间内（包含两端）
+		values, err := b.values(field, splitValues(s.Val))
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 2 {
+			return nil, paramErrorf("字段 %s 的 BT 条件需要两个值", s.Param)
+		}
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{column, values[0], values[1]}}, nil
+	}
+
+	if len(s.Val) != 1 {
+		return nil, paramErrorf("字段 %s 的 %s 条件需要一个值", s.Param, s.Sign)
+	}
+	val := s.Val[0]
+
+	switch sign {
+	case "LK", "NLK", "SW", "EW":
+		// 模糊查询，只支持字符串字段
+		if !isString {
+			return nil, paramErrorf("字段 %s 不支持模糊查询", s.Param)
+		}
+		pattern := likeEscaper.Replace(val)
 		switch sign {
-		case "EQ":
-			// 查询等于某个值
-			db = db.Where(s.Param+" = ?", s.Val)
-		case "NEQ":
-			// 查询不等于某个值
-			db = db.Where(s.Param+" <> ?", s.Val)
-		case "LK":
-			// 模糊查询
-			db = db.Where(s.Param+" LIKE ?", "%"+s.Val+"%")
-		case "IN":
-			// 查询某个值在某个范围内
-			db = db.Where(s.Param+" IN (?)", s.Val)
-		case "GT":
-			// 查询大于某个值
-			db = db.Where(s.Param+" > ?", s.Val)
-		case "GTE":
-			// 查询大于等于某个值
-			db = db.Where(s.Param+" >= ?", s.Val)
-		case "LT":
-			// 查询小于某个值
-			db = db.Where(s.Param+" < ?", s.Val)
-		case "LTE":
-			// 查询小于等于某个值
-			db = db.Where(s.Param+" <= ?", s.Val)
+		case "SW":
+			pattern = pattern + "%" // 以某个值开头
+		case "EW":
+			pattern = "%" + pattern // 以某个值结尾
 		default:
+			pattern = "%" + pattern + "%" // 包含（不包含）某个值
 		}
+		if sign == "NLK" {
+			return clause.Not(clause.Like{Column: column, Value: pattern}), nil
+		}
+		return clause.Like{Column: column, Value: pattern}, nil
+	}
+
+	value, err := convertValue(field, val)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sign {
+	case "EQ":
+		// 查询等于某个值
+		return clause.Eq{Column: column, Value: value}, nil
+	case "NEQ":
+		// 查询不等于某个值
+		return clause.Neq{Column: column, Value: value}, nil
+	case "GT":
+		// 查询大于某个值
+		return clause.Gt{Column: column, Value: value}, nil
+	case "GTE":
+		// 查询大于等于某个值
+		return clause.Gte{Column: column, Value: value}, nil
+	case "LT":
+		// 查询小于某个值
+		return clause.Lt{Column: column, Value: value}, nil
+	case "LTE":
+		// 查询小于等于某个值
+		return clause.Lte{Column: column, Value: value}, nil
+	}
+
+	return nil, paramErrorf("不支持的查询条件 %s", s.Sign)
+}
+
+// values 按字段类型转换多个值
+func (b *searchBuilder) values(field *schema.Field, vals []string) ([]any, error) {
+	values := make([]any, 0, len(vals))
+	for _, val := range vals {
+		value, err := convertValue(field, val)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// splitValues 兼容旧格式：只有一个字符串值时按逗号分隔
+func splitValues(vals SearchValue) []string {
+	if len(vals) != 1 {
+		return vals
 	}
-	return db
+
+	var values []string
+	for _, val := range strings.Split(vals[0], ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			values = append(values, val)
+		}
+	}
+
+	return values
 }
 
-// GetQuery 获取sql查询语句
-func GetQuery(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+// filterFields 获取模型允许过滤的字段，key 为数据库列名
+func filterFields(db *gorm.DB, model any) (map[string]*schema.Field, error) {
+	filterQuerier, ok := model.(FilterQuerier)
+	if !ok {
+		return nil, paramErrorf("该列表不支持按条件查询")
+	}
+
+	return lookUpFields(db, model, filterQuerier.FilterQueryFields())
+}
+
+// lookUpFields 按字段名解析模型字段，key 为数据库列名，模型声明了不存在的字段时返回错误
+func lookUpFields(db *gorm.DB, model any, names []string) (map[string]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]*schema.Field, len(names))
+	for _, name := range names {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("模型 %s 的字段 %s 不存在", stmt.Schema.Name, name)
+		}
+		fields[field.DBName] = field
+	}
+
+	return fields, nil
+}
+
+// convertValue 将字符串值转换为字段对应的 Go 类型
+func convertValue(field *schema.Field, val string) (any, error) {
+	fieldType := field.IndirectFieldType
+
+	if fieldType == reflect.TypeOf(time.Time{}) {
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, paramErrorf("字段 %s 的值 %q 不是有效的时间，格式为 2006-01-02 或 2006-01-02 15:04:05", field.DBName, val)
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return val, nil
+	case reflect.Bool:
+		v, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, paramErrorf("字段 %s 的值 %q 不是有效的布尔值", field.DBName, val)
+		}
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(val, 10, fieldType.Bits())
+		if err != nil {
+			return nil, paramErrorf("字段 %s 的值 %q 不是有效的整数", field.DBName, val)
+		}
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(val, 10, fieldType.Bits())
+		if err != nil {
+			return nil, paramErrorf("字段 %s 的值 %q 不是有效的非负整数", field.DBName, val)
+		}
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(val, fieldType.Bits())
+		if err != nil {
+			return nil, paramErrorf("字段 %s 的值 %q 不是有效的数字", field.DBName, val)
+		}
+		return v, nil
+	}
+
+	return nil, paramErrorf("字段 %s 不支持按条件查询", field.DBName)
+}
+
+// ParseSearchParams 解析 params 参数，可以是条件数组（AND 关系）或单个条件组对象
+func ParseSearchParams(params string) ([]SearchParam, error) {
+	var searchParamSlice []SearchParam
+	if strings.HasPrefix(strings.TrimSpace(params), "{") {
+		var group SearchParam
+		if err := json.Unmarshal([]byte(params), &group); err != nil {
+			return nil, paramErrorf("搜索参数解析失败: %v, 原始参数: %s", err, params)
+		}
+		return []SearchParam{group}, nil
+	}
+
+	if err := json.Unmarshal([]byte(params), &searchParamSlice); err != nil {
+		return nil, paramErrorf("搜索参数解析失败: %v, 原始参数: %s", err, params)
+	}
+
+	return searchParamSlice, nil
+}
+
+// GetQuery 获取sql查询语句，列表视图的查询条件与 params 参数为 AND 关系
+func GetQuery(c *gin.Context, db *gorm.DB, model any, view *View) (*gorm.DB, error) {
 	// 判断是否是get请求
 	if c.Request.Method != "GET" {
-		return nil, fmt.Errorf("请求方式错误，请使用GET请求")
+		return nil, paramErrorf("请求方式错误，请使用GET请求")
 	}
 
 	// 解析搜索参数
 	var searchParamSlice []SearchParam
-	paramsStr := c.Query("params")
-	if paramsStr == "" {
-		return db, nil // 如果没有搜索参数，直接返回原始查询
+	if paramsStr := c.Query("params"); paramsStr != "" {
+		// 解码 URL 编码的参数
+		decodedParams, err := url.QueryUnescape(paramsStr)
+		if err != nil {
+			return nil, paramErrorf("URL解码失败: %v", err)
+		}
+
+		if searchParamSlice, err = ParseSearchParams(decodedParams); err != nil {
+			return nil, err
+		}
 	}
 
-	// 解码 URL 编码的参数
-	decodedParams, err := url.QueryUnescape(paramsStr)
-	if err != nil {
-		return nil, fmt.Errorf("URL解码失败: %v", err)
+	// 列表视图的查询条件
+	if view != nil && view.Params != "" {
+		viewParams, err := ParseSearchParams(view.Params)
+		if err != nil {
+			return nil, err
+		}
+		searchParamSlice = append(searchParamSlice, viewParams...)
 	}
 
-	if err := json.Unmarshal([]byte(decodedParams), &searchParamSlice); err != nil {
-		return nil, fmt.Errorf("搜索参数解析失败: %v, 原始参数: %s", err, decodedParams)
+	if len(searchParamSlice) == 0 {
+		return db, nil // 如果没有搜索参数，直接返回原始查询
 	}
 
 	// 构造查询语句
-	query := BuildQuery(db, searchParamSlice)
+	query, err := BuildQuery(db, model, searchParamSlice)
+	if err != nil {
+		return nil, err
+	}
 	if query.Error != nil {
 		return nil, query.Error
 	}
@@ -0,0 +1,324 @@
+package query_test
+
+import (
+	"ffly-baisc/pkg/query"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBuildQueryTypedValues 值按字段类型转换
+func TestBuildQueryTypedValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		param    query.SearchParam
+		wantSQL  string
+		wantVars string
+	}{
+		{
+			name:     "整数",
+			param:    query.SearchParam{Param: "status", Sign: "EQ", Val: query.SearchValue{"1"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`status` = ?",
+			wantVars: "[1]",
+		},
+		{
+			name:     "非负整数",
+			param:    query.SearchParam{Param: "id", Sign: "GT", Val: query.SearchValue{"10"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`id` > ?",
+			wantVars: "[10]",
+		},
+		{
+			name:     "布尔值",
+			param:    query.SearchParam{Param: "enabled", Sign: "EQ", Val: query.SearchValue{"true"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`enabled` = ?",
+			wantVars: "[true]",
+		},
+		{
+			name:     "浮点数",
+			param:    query.SearchParam{Param: "score", Sign: "LTE", Val: query.SearchValue{"9.5"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`score` <= ?",
+			wantVars: "[9.5]",
+		},
+		{
+			name:     "指针字段",
+			param:    query.SearchParam{Param: "email", Sign: "NEQ", Val: query.SearchValue{"a@example.com"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`email` <> ?",
+			wantVars: "[a@example.com]",
+		},
+		{
+			name:    "时间",
+			param:   query.SearchParam{Param: "created_at", Sign: "GTE", Val: query.SearchValue{"2024-01-02"}},
+			wantSQL: "SELECT * FROM `users` WHERE `users`.`created_at` >= ?",
+		},
+		{
+			name:     "模糊查询转义通配符",
+			param:    query.SearchParam{Param: "username", Sign: "LK", Val: query.SearchValue{"a_b%"}},
+			wantSQL:  "SELECT * FROM `users` WHERE `users`.`username` LIKE ?",
+			wantVars: `[%a\_b\%%]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dryDB(t)
+			tx, err := query.BuildQuery(db, &testUser{}, []query.SearchParam{tt.param})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sql, vars := findSQL(tx)
+			if sql != tt.wantSQL {
+				t.Errorf("SQL = %s\n期望 %s", sql, tt.wantSQL)
+			}
+			if tt.wantVars != "" && vars != tt.wantVars {
+				t.Errorf("参数 = %s，期望 %s", vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+// TestBuildQueryParamErrors 未知字段、非法的值等返回 ParamError
+func TestBuildQueryParamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		param query.SearchParam
+	}{
+		{"不在白名单中的字段", query.SearchParam{Param: "password", Sign: "EQ", Val: query.SearchValue{"x"}}},
+		{"不存在的字段", query.SearchParam{Param: "nope", Sign: "EQ", Val: query.SearchValue{"x"}}},
+		{"非法的整数", query.SearchParam{Param: "status", Sign: "EQ", Val: query.SearchValue{"abc"}}},
+		{"超出范围的整数", query.SearchParam{Param: "status", Sign: "EQ", Val: query.SearchValue{"300"}}},
+		{"负数的非负整数", query.SearchParam{Param: "id", Sign: "EQ", Val: query.SearchValue{"-1"}}},
+		{"非法的布尔值", query.SearchParam{Param: "enabled", Sign: "EQ", Val: query.SearchValue{"yes"}}},
+		{"非法的时间", query.SearchParam{Param: "created_at", Sign: "GT", Val: query.SearchValue{"yesterday"}}},
+		{"非字符串字段模糊查询", query.SearchParam{Param: "status", Sign: "LK", Val: query.SearchValue{"1"}}},
+		{"不支持的条件", query.SearchParam{Param: "status", Sign: "REGEX", Val: query.SearchValue{"1"}}},
+		{"缺少值", query.SearchParam{Param: "status", Sign: "EQ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := query.BuildQuery(dryDB(t), &testUser{}, []query.SearchParam{tt.param})
+			assertParamError(t, err)
+		})
+	}
+
+	t.Run("模型没有过滤白名单", func(t *testing.T) {
+		_, err := query.BuildQuery(dryDB(t), &testLog{}, []query.SearchParam{{Param: "path", Sign: "EQ", Val: query.SearchValue{"/"}}})
+		assertParamError(t, err)
+	})
+}
+
+func TestGetQuery(t *testing.T) {
+	t.Run("params 参数", func(t *testing.T) {
+		params := url.QueryEscape(`[{"param":"status","sign":"EQ","val":1}]`)
+		tx, err := query.GetQuery(newContext("params="+params), dryDB(t), &testUser{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql, vars := findSQL(tx); sql != "SELECT * FROM `users` WHERE `users`.`status` = ?" || vars != "[1]" {
+			t.Errorf("SQL = %s %s", sql, vars)
+		}
+	})
+
+	t.Run("列表视图的条件与 params 为 AND 关系", func(t *testing.T) {
+		params := url.QueryEscape(`[{"param":"status","sign":"EQ","val":1}]`)
+		view := &query.View{Params: `[{"param":"username","sign":"EQ","val":"admin"}]`}
+		tx, err := query.GetQuery(newContext("params="+params), dryDB(t), &testUser{}, view)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "SELECT * FROM `users` WHERE `users`.`status` = ? AND `users`.`username` = ?"
+		if sql, _ := findSQL(tx); sql != want {
+			t.Errorf("SQL = %s\n期望 %s", sql, want)
+		}
+	})
+
+	t.Run("非法的 JSON", func(t *testing.T) {
+		_, err := query.GetQuery(newContext("params="+url.QueryEscape(`[{"param":`)), dryDB(t), &testUser{}, nil)
+		assertParamError(t, err)
+	})
+
+	t.Run("非 GET 请求", func(t *testing.T) {
+		c := newContext("")
+		c.Request = httptest.NewRequest("POST", "/", nil)
+		_, err := query.GetQuery(c, dryDB(t), &testUser{}, nil)
+		assertParamError(t, err)
+	})
+}
+
+// buildSQL 解析 params 参数并生成 SQL
+func buildSQL(t *testing.T, params string) (string, string, error) {
+	t.Helper()
+
+	searchParamSlice, err := query.ParseSearchParams(params)
+	if err != nil {
+		return "", "", err
+	}
+	tx, err := query.BuildQuery(dryDB(t), &testUser{}, searchParamSlice)
+	if err != nil {
+		return "", "", err
+	}
+	sql, vars := findSQL(tx)
+
+	return sql, vars, nil
+}
+
+func TestParseSearchParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []query.SearchParam
+	}{
+		{
+			name:   "条件数组",
+			params: `[{"param":"status","sign":"EQ","val":1},{"param":"enabled","sign":"EQ","val":true}]`,
+			want: []query.SearchParam{
+				{Param: "status", Sign: "EQ", Val: query.SearchValue{"1"}},
+				{Param: "enabled", Sign: "EQ", Val: query.SearchValue{"true"}},
+			},
+		},
+		{
+			name:   "条件组对象",
+			params: ` {"logic":"OR","params":[{"param":"id","sign":"IN","val":[1,"2"]}]}`,
+			want: []query.SearchParam{
+				{Logic: "OR", Params: []query.SearchParam{{Param: "id", Sign: "IN", Val: query.SearchValue{"1", "2"}}}},
+			},
+		},
+		{
+			name:   "大整数不丢失精度",
+			params: `[{"param":"id","sign":"EQ","val":18446744073709551615}]`,
+			want:   []query.SearchParam{{Param: "id", Sign: "EQ", Val: query.SearchValue{"18446744073709551615"}}},
+		},
+		{
+			name:   "null 值",
+			params: `[{"param":"email","sign":"NULL","val":null}]`,
+			want:   []query.SearchParam{{Param: "email", Sign: "NULL"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.ParseSearchParams(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSearchParams() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, params := range []string{
+		`[{"param":`,
+		`{"logic":"OR","params":`,
+		`[{"param":"status","sign":"EQ","val":{"a":1}}]`,
+		`[{"param":"status","sign":"IN","val":[[1]]}]`,
+	} {
+		_, err := query.ParseSearchParams(params)
+		assertParamError(t, err)
+	}
+}
+
+// TestBuildQueryOperators 各查询条件生成的 SQL
+func TestBuildQueryOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   string
+		wantSQL  string
+		wantVars string
+	}{
+		{"不包含", `[{"param":"username","sign":"NLK","val":"ad"}]`, "SELECT * FROM `users` WHERE `users`.`username` NOT LIKE ?", "[%ad%]"},
+		{"开头", `[{"param":"username","sign":"SW","val":"ad"}]`, "SELECT * FROM `users` WHERE `users`.`username` LIKE ?", "[ad%]"},
+		{"结尾", `[{"param":"username","sign":"EW","val":"ad"}]`, "SELECT * FROM `users` WHERE `users`.`username` LIKE ?", "[%ad]"},
+		{"IN 数组", `[{"param":"status","sign":"IN","val":[1,2]}]`, "SELECT * FROM `users` WHERE `users`.`status` IN (?,?)", "[1 2]"},
+		{"IN 逗号分隔", `[{"param":"status","sign":"IN","val":"1, 2"}]`, "SELECT * FROM `users` WHERE `users`.`status` IN (?,?)", "[1 2]"},
+		{"NOT IN", `[{"param":"id","sign":"NIN","val":[3]}]`, "SELECT * FROM `users` WHERE `users`.`id` <> ?", "[3]"},
+		{"区间", `[{"param":"score","sign":"BT","val":[1,9.5]}]`, "SELECT * FROM `users` WHERE `users`.`score` BETWEEN ? AND ?", "[1 9.5]"},
+		{"为空", `[{"param":"email","sign":"NULL"}]`, "SELECT * FROM `users` WHERE `users`.`email` IS NULL", "[]"},
+		{"不为空", `[{"param":"email","sign":"NNULL"}]`, "SELECT * FROM `users` WHERE `users`.`email` IS NOT NULL", "[]"},
+		{"忽略大小写", `[{"param":"username","sign":"EQ","val":"Admin","ci":true}]`, "SELECT * FROM `users` WHERE LOWER(`users`.`username`) = ?", "[admin]"},
+		{"小写的条件", `[{"param":"status","sign":"gte","val":1}]`, "SELECT * FROM `users` WHERE `users`.`status` >= ?", "[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars, err := buildSQL(t, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL || vars != tt.wantVars {
+				t.Errorf("SQL = %s %s\n期望 %s %s", sql, vars, tt.wantSQL, tt.wantVars)
+			}
+		})
+	}
+}
+
+// TestBuildQueryGroups 条件组可嵌套，只有一个条件的组不影响外层的逻辑关系
+func TestBuildQueryGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantSQL string
+	}{
+		{
+			name:    "AND 中嵌套 OR",
+			params:  `[{"param":"status","sign":"EQ","val":1},{"logic":"OR","params":[{"param":"username","sign":"SW","val":"ad"},{"param":"email","sign":"NULL"}]}]`,
+			wantSQL: "SELECT * FROM `users` WHERE `users`.`status` = ? AND (`users`.`username` LIKE ? OR `users`.`email` IS NULL)",
+		},
+		{
+			name:    "顶层 OR 组",
+			params:  `{"logic":"or","params":[{"param":"status","sign":"EQ","val":1},{"param":"status","sign":"EQ","val":2}]}`,
+			wantSQL: "SELECT * FROM `users` WHERE (`users`.`status` = ? OR `users`.`status` = ?)",
+		},
+		{
+			name:    "只有一个条件的 OR 组",
+			params:  `[{"param":"status","sign":"EQ","val":1},{"logic":"OR","params":[{"param":"email","sign":"NULL"}]}]`,
+			wantSQL: "SELECT * FROM `users` WHERE `users`.`status` = ? AND `users`.`email` IS NULL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := buildSQL(t, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("SQL = %s\n期望 %s", sql, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestBuildQueryOperatorErrors(t *testing.T) {
+	// 嵌套 6 层的条件组
+	nested := `{"param":"status","sign":"EQ","val":1}`
+	for i := 0; i < 5; i++ {
+		nested = `{"logic":"AND","params":[` + nested + `]}`
+	}
+
+	// 51 个条件
+	conditions := make([]string, 51)
+	for i := range conditions {
+		conditions[i] = `{"param":"status","sign":"EQ","val":1}`
+	}
+
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"BT 只有一个值", `[{"param":"score","sign":"BT","val":[1]}]`},
+		{"IN 没有值", `[{"param":"status","sign":"IN","val":[]}]`},
+		{"IN 中有非法的值", `[{"param":"status","sign":"IN","val":[1,"x"]}]`},
+		{"EQ 多个值", `[{"param":"status","sign":"EQ","val":[1,2]}]`},
+		{"非字符串字段忽略大小写", `[{"param":"status","sign":"EQ","val":1,"ci":true}]`},
+		{"空的条件组", `[{"logic":"OR","params":[]}]`},
+		{"条件组同时包含 param", `[{"logic":"OR","param":"status","params":[{"param":"status","sign":"NULL"}]}]`},
+		{"不支持的逻辑关系", `[{"logic":"XOR","params":[{"param":"status","sign":"NULL"},{"param":"email","sign":"NULL"}]}]`},
+		{"嵌套层数过多", `[` + nested + `]`},
+		{"条件数量过多", `[` + strings.Join(conditions, ",") + `]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := buildSQL(t, tt.params)
+			assertParamError(t, err)
+		})
+	}
+}
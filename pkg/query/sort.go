@@ -0,0 +1,129 @@
+package query
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SortQuerier 定义可排序字段的接口，未实现该接口的模型不允许使用 sort 排序
+type SortQuerier interface {
+	SortQueryFields() []string // 返回允许排序的字段（数据库列名）
+	DefaultSort() string       // 返回默认排序，格式同 sort 参数，如 -created_at
+}
+
+// SortParam 排序字段
+type SortParam struct {
+	Field string // 字段名（数据库列名）
+	Desc  bool   // 是否降序
+}
+
+// ParseSort 解析排序参数，多个字段使用逗号分隔，字段前加 - 表示降序，如 -created_at,username
+func ParseSort(sort string) ([]SortParam, error) {
+	var sortParams []SortParam
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		sortParam := SortParam{Field: item}
+		if strings.HasPrefix(item, "-") {
+			sortParam = SortParam{Field: item[1:], Desc: true}
+		} else if strings.HasPrefix(item, "+") {
+			sortParam.Field = item[1:]
+		}
+		if sortParam.Field == "" {
+			return nil, paramErrorf("排序参数 %s 格式有误", sort)
+		}
+		if seen[sortParam.Field] {
+			return nil, paramErrorf("排序字段 %s 重复", sortParam.Field)
+		}
+		seen[sortParam.Field] = true
+
+		sortParams = append(sortParams, sortParam)
+	}
+
+	return sortParams, nil
+}
+
+// GetSort 获取排序字段，优先使用 sort 参数，其次使用列表视图的排序，最后使用模型的默认排序
+// 排序字段必须在模型的排序字段白名单中，最后追加主键保证分页结果稳定
+func GetSort(c *gin.Context, db *gorm.DB, model any, view *View) ([]SortParam, error) {
+	sort := c.Query("sort")
+	if sort == "" && view != nil {
+		sort = view.Sort
+	}
+
+	return ResolveSort(db, model, sort)
+}
+
+// ResolveSort 解析并校验排序参数，为空时使用模型的默认排序
+func ResolveSort(db *gorm.DB, model any, sort string) ([]SortParam, error) {
+	sortQuerier, ok := model.(SortQuerier)
+	if !ok {
+		if sort != "" {
+			return nil, paramErrorf("该列表不支持排序")
+		}
+		return nil, nil
+	}
+
+	if sort == "" {
+		sort = sortQuerier.DefaultSort()
+	}
+	sortParams, err := ParseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := lookUpFields(db, model, sortQuerier.SortQueryFields())
+	if err != nil {
+		return nil, err
+	}
+	for _, sortParam := range sortParams {
+		if _, ok := fields[sortParam.Field]; !ok {
+			return nil, paramErrorf("不支持按字段 %s 排序", sortParam.Field)
+		}
+	}
+
+	// 追加主键，方向与最后一个排序字段一致
+	hasID := false
+	for _, sortParam := range sortParams {
+		if sortParam.Field == "id" {
+			hasID = true
+		}
+	}
+	if !hasID && len(sortParams) > 0 {
+		sortParams = append(sortParams, SortParam{Field: "id", Desc: sortParams[len(sortParams)-1].Desc})
+	}
+
+	return sortParams, nil
+}
+
+// BuildSort 构造排序语句
+func BuildSort(db *gorm.DB, sortParams []SortParam) *gorm.DB {
+	for _, sortParam := range sortParams {
+		db = db.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: sortParam.Field},
+			Desc:   sortParam.Desc,
+		})
+	}
+
+	return db
+}
+
+// withSortFields 在查询字段中补充排序字段
+func withSortFields(fields []string, sortParams []SortParam) []string {
+	result := slices.Clone(fields)
+	for _, sortParam := range sortParams {
+		if !slices.Contains(result, sortParam.Field) {
+			result = append(result, sortParam.Field)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,107 @@
+package query_test
+
+import (
+	"ffly-baisc/pkg/query"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort    string
+		want    []query.SortParam
+		wantErr bool
+	}{
+		{sort: "", want: nil},
+		{sort: "-created_at,username", want: []query.SortParam{{Field: "created_at", Desc: true}, {Field: "username"}}},
+		{sort: " +id , -status ", want: []query.SortParam{{Field: "id"}, {Field: "status", Desc: true}}},
+		{sort: "username,,id", want: []query.SortParam{{Field: "username"}, {Field: "id"}}},
+		{sort: "username,-username", wantErr: true},
+		{sort: "-", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := query.ParseSort(tt.sort)
+		if tt.wantErr {
+			assertParamError(t, err)
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSort(%q) err = %v", tt.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSort(%q) = %v，期望 %v", tt.sort, got, tt.want)
+		}
+	}
+}
+
+// TestResolveSort 使用默认排序、校验白名单，并追加主键保证分页稳定
+func TestResolveSort(t *testing.T) {
+	tests := []struct {
+		name string
+		sort string
+		want []query.SortParam
+	}{
+		{"默认排序", "", []query.SortParam{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}}},
+		{"追加主键", "username", []query.SortParam{{Field: "username"}, {Field: "id"}}},
+		{"主键方向与最后一个字段一致", "status,-email", []query.SortParam{{Field: "status"}, {Field: "email", Desc: true}, {Field: "id", Desc: true}}},
+		{"已包含主键", "-id,username", []query.SortParam{{Field: "id", Desc: true}, {Field: "username"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.ResolveSort(dryDB(t), &testUser{}, tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveSort(%q) = %v，期望 %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("不在白名单中的字段", func(t *testing.T) {
+		_, err := query.ResolveSort(dryDB(t), &testUser{}, "password")
+		assertParamError(t, err)
+	})
+
+	t.Run("模型不支持排序", func(t *testing.T) {
+		_, err := query.ResolveSort(dryDB(t), &testLog{}, "id")
+		assertParamError(t, err)
+
+		got, err := query.ResolveSort(dryDB(t), &testLog{}, "")
+		if err != nil || got != nil {
+			t.Errorf("未指定排序时应返回 nil，实际 %v, %v", got, err)
+		}
+	})
+}
+
+// TestGetSort sort 参数优先于列表视图的排序
+func TestGetSort(t *testing.T) {
+	view := &query.View{Sort: "username"}
+
+	got, err := query.GetSort(newContext(""), dryDB(t), &testUser{}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []query.SortParam{{Field: "username"}, {Field: "id"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("使用视图排序 = %v，期望 %v", got, want)
+	}
+
+	got, err = query.GetSort(newContext("sort=-status"), dryDB(t), &testUser{}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []query.SortParam{{Field: "status", Desc: true}, {Field: "id", Desc: true}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sort 参数 = %v，期望 %v", got, want)
+	}
+}
+
+func TestBuildSort(t *testing.T) {
+	db := dryDB(t)
+	tx := query.BuildSort(db.Model(&testUser{}), []query.SortParam{{Field: "username"}, {Field: "id", Desc: true}})
+
+	want := "SELECT * FROM `users` ORDER BY `users`.`username`,`users`.`id` DESC"
+	if sql, _ := findSQL(tx); sql != want {
+		t.Errorf("SQL = %s\n期望 %s", sql, want)
+	}
+}
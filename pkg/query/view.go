@@ -0,0 +1,72 @@
+package query
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// View 保存的列表视图（查询条件 + 排序 + 查询字段），列表通过 view=<id> 参数应用
+// 请求中的 sort / fields 参数优先于视图，params 参数与视图的查询条件为 AND 关系
+type View struct {
+	Params string   // 查询条件，格式同 params 参数
+	Sort   string   // 排序，格式同 sort 参数
+	Fields []string // 查询字段
+}
+
+// ViewLoader 加载列表视图，resource 为列表的表名（如 users），找不到或无权使用时返回 ParamError
+type ViewLoader func(c *gin.Context, resource string, id uint) (*View, error)
+
+var viewLoader ViewLoader
+
+// SetViewLoader 注册列表视图加载器，视图由业务层存储，未注册时不支持 view 参数
+func SetViewLoader(loader ViewLoader) {
+	viewLoader = loader
+}
+
+// GetView 获取 view 参数对应的列表视图，未指定时返回 nil
+func GetView(c *gin.Context, db *gorm.DB, model any) (*View, error) {
+	viewID := c.Query("view")
+	if viewID == "" {
+		return nil, nil
+	}
+	if viewLoader == nil {
+		return nil, paramErrorf("该列表不支持视图")
+	}
+
+	id, err := strconv.ParseUint(viewID, 10, 64)
+	if err != nil {
+		return nil, paramErrorf("无效的视图ID %s", viewID)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	return viewLoader(c, stmt.Schema.Table, uint(id))
+}
+
+// ValidateView 按模型的白名单校验列表视图，保存视图前调用
+func ValidateView(db *gorm.DB, model any, view *View) error {
+	if view.Params != "" {
+		searchParamSlice, err := ParseSearchParams(view.Params)
+		if err != nil {
+			return err
+		}
+		if _, err := BuildQuery(db, model, searchParamSlice); err != nil {
+			return err
+		}
+	}
+
+	if _, err := ResolveSort(db, model, view.Sort); err != nil {
+		return err
+	}
+
+	if _, err := ResolveFields(db, model, view.Fields); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -15,10 +15,12 @@ type Response struct {
 }
 
 type PageResponse struct {
-	List  any   `json:"list"`
-	Total int64 `json:"total"`
-	Page  int   `json:"page"`
-	Size  int   `json:"size"`
+	List       any    `json:"list"`
+	Total      *int64 `json:"total,omitempty"` // 游标分页未统计总数时不返回
+	Page       int    `json:"page,omitempty"`  // 游标分页没有页码
+	Size       int    `json:"size"`
+	NextCursor string `json:"nextCursor,omitempty"` // 游标分页的下一页游标
+	PrevCursor string `json:"prevCursor,omitempty"` // 游标分页的上一页游标
 }
 
 func Success(c *gin.Context, data any, p *query.Pagination, message string) {
@@ -51,16 +53,31 @@ func Error(c *gin.Context, httpCode int, message string, err error) {
 	})
 }
 
+// ErrorWithData 错误响应并附带数据，用于告知前端下一步需要的操作
+func ErrorWithData(c *gin.Context, httpCode int, message string, err error, data any) {
+	if err != nil {
+		message = err.Error()
+	}
+	c.JSON(httpCode, Response{
+		Success: false,
+		Code:    httpCode,
+		Message: message,
+		Data:    data,
+	})
+}
+
 func SuccessWithPagination(c *gin.Context, data any, p *query.Pagination, message string) {
 	if message == "" {
 		message = "success"
 	}
 
 	var dataResult = PageResponse{
-		List:  data,
-		Total: *p.Total,
-		Page:  p.Page,
-		Size:  p.Size,
+		List:       data,
+		Total:      p.Total,
+		Page:       p.Page,
+		Size:       p.Size,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
 	}
 	c.JSON(http.StatusOK, Response{
 		Success: true,
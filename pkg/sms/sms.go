@@ -0,0 +1,90 @@
+package sms
+
+import (
+	"ffly-baisc/internal/config"
+	"log"
+	"sync"
+)
+
+// Sender 短信发送接口
+type Sender interface {
+	Send(phone string, content string) error
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]func() Sender{
+		"log":  func() Sender { return &LogSender{} },
+		"fake": func() Sender { return DefaultFakeSender },
+	}
+)
+
+// RegisterDriver 注册短信驱动，接入短信服务商时在其包的 init 中调用，配置 sms.driver 为驱动名称即可使用
+func RegisterDriver(name string, factory func() Sender) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = factory
+}
+
+// NewSender 根据配置创建短信发送器，未知驱动时使用 log（仅打印日志，用于开发环境）
+func NewSender() Sender {
+	driversMu.RLock()
+	factory, ok := drivers[config.GlobalConfig.SMS.Driver]
+	driversMu.RUnlock()
+	if !ok {
+		return &LogSender{}
+	}
+
+	return factory()
+}
+
+// LogSender 仅打印日志的短信发送器
+type LogSender struct{}
+
+// Send 打印短信内容
+func (s *LogSender) Send(phone string, content string) error {
+	log.Printf("[sms] to: %s, content: %s\n", phone, content)
+	return nil
+}
+
+// Message 已发送的短信
+type Message struct {
+	Phone   string
+	Content string
+}
+
+// DefaultFakeSender fake 驱动使用的发送器
+var DefaultFakeSender = &FakeSender{}
+
+// FakeSender 本地假短信发送器，只把短信保存在内存中，用于联调和自动化测试读取验证码
+type FakeSender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send 保存短信
+func (s *FakeSender) Send(phone string, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, Message{Phone: phone, Content: content})
+	return nil
+}
+
+// LastMessage 获取发送给某个手机号的最后一条短信
+func (s *FakeSender) LastMessage(phone string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Phone == phone {
+			return s.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset 清空已保存的短信
+func (s *FakeSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
@@ -0,0 +1,119 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 测试向量的 SHA1 密钥 "12345678901234567890"（Base32 编码）
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestGenerateCodeRFC6238 RFC 6238 附录 B 的 SHA1 测试向量，原始为 8 位，这里取后 6 位
+func TestGenerateCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		code, err := GenerateCode(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateCode(T=%d) = %s，期望 %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestGenerateCodeSecretFormat(t *testing.T) {
+	// 小写和补齐的 = 都可以解码
+	lower, err := GenerateCode(strings.ToLower(rfc6238Secret)+"====", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upper, _ := GenerateCode(rfc6238Secret, 1)
+	if lower != upper {
+		t.Errorf("小写密钥生成的验证码 %s 与大写 %s 不一致", lower, upper)
+	}
+
+	if _, err := GenerateCode("not base32!", 1); err == nil {
+		t.Error("非法密钥应返回错误")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(step int64) string {
+		code, err := GenerateCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"当前时间步", code(step), 1, step, true},
+		{"前后空白", " " + code(step) + " ", 1, step, true},
+		{"上一个时间步", code(step - 1), 1, step - 1, true},
+		{"下一个时间步", code(step + 1), 1, step + 1, true},
+		{"超出误差", code(step - 2), 1, 0, false},
+		{"不允许误差", code(step - 1), 0, 0, false},
+		{"位数不对", "12345", 1, 0, false},
+		{"错误的验证码", "000000", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate() = (%d, %v)，期望 (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 20 {
+		t.Errorf("密钥长度 = %d 字节，期望 20", len(key))
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI("ffly", "admin", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/ffly:admin" {
+		t.Errorf("ProvisioningURI() = %s", uri)
+	}
+
+	query := uri.Query()
+	for key, want := range map[string]string{"secret": rfc6238Secret, "issuer": "ffly", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("参数 %s = %s，期望 %s", key, got, want)
+		}
+	}
+}
@@ -10,11 +10,13 @@ type Status int
 const (
 	StatusEnabled  Status = iota + 1 // 启用
 	StatusDisabled                   // 禁用
+	StatusPending                    // 待验证（注册后未完成邮箱/手机号验证）
 )
 
 var statusNames = map[Status]string{
 	StatusEnabled:  "启用",
 	StatusDisabled: "禁用",
+	StatusPending:  "待验证",
 }
 
 // String 获取状态名称
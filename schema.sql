@@ -19,7 +19,14 @@ create table if not exists `users` (
   `nickname` varchar(50) default null comment '昵称',
   `email` varchar(100) default null comment '邮箱',
   `phone` varchar(20) default null comment '手机号',
-  `status` tinyint unsigned not null default '1' comment '状态 1: 启用 2: 禁用',
+  `email_verified` boolean not null default false comment '邮箱是否已验证',
+  `phone_verified` boolean not null default false comment '手机号是否已验证',
+  `status` tinyint unsigned not null default '1' comment '状态 1: 启用 2: 禁用 3: 待验证',
+  `two_factor_enabled` boolean not null default false comment '是否开启两步验证',
+  `two_factor_secret` varchar(64) default null comment '两步验证 TOTP 密钥',
+  `password_changed_at` timestamp null default null comment '密码修改时间',
+  `must_change_password` boolean not null default false comment '下次登录时是否必须修改密码',
+  `auth_source` varchar(20) not null default '' comment '认证方式 local: 本地密码 ldap: LDAP 目录，为空时使用系统默认',
   `created_at` timestamp not null default current_timestamp comment '创建时间',
   `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
   `deleted_at` timestamp null default null comment '删除时间',
@@ -31,6 +38,77 @@ create table if not exists `users` (
   key `idx_deleted_at` (`deleted_at`) -- 索引 deleted_at
 ) engine=innodb auto_increment=1 comment='用户表';
 
+-- 创建两步验证恢复码表
+create table if not exists `user_recovery_codes` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '用户id',
+  `code_hash` char(64) not null comment '恢复码 SHA-256 哈希',
+  `used_at` timestamp null default null comment '使用时间',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  unique key `uk_user_code` (`user_id`, `code_hash`), -- 联合唯一索引 user_id, code_hash
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_user_recovery_codes_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade -- 引用 users.id 并设置级联删除和更新
+) engine=innodb auto_increment=1 comment='两步验证恢复码表';
+
+-- 创建外部身份绑定表
+create table if not exists `user_identities` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '用户id',
+  `provider` varchar(50) not null comment '身份提供方标识',
+  `subject` varchar(255) not null comment '身份提供方中的用户唯一标识（sub）',
+  `email` varchar(100) not null default '' comment '身份提供方中的邮箱',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  unique key `uk_provider_subject` (`provider`, `subject`), -- 联合唯一索引 provider, subject
+  key `idx_user_id` (`user_id`), -- 索引 user_id
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_user_identities_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade -- 引用 users.id 并设置级联删除和更新
+) engine=innodb auto_increment=1 comment='外部身份绑定表';
+
+-- 创建历史密码表
+create table if not exists `password_histories` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '用户id',
+  `password` varchar(255) not null comment '历史密码（加密）',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  key `idx_user_id` (`user_id`), -- 索引 user_id
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_password_histories_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade -- 引用 users.id 并设置级联删除和更新
+) engine=innodb auto_increment=1 comment='历史密码表';
+
+-- 创建个人 API Key 表
+create table if not exists `api_keys` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '用户id',
+  `name` varchar(50) not null comment '名称',
+  `prefix` varchar(20) not null comment 'Key 前缀，用于识别 Key',
+  `key_hash` char(64) not null comment 'Key SHA-256 哈希',
+  `scopes` json not null comment '权限范围（权限码列表）',
+  `expires_at` timestamp null default null comment '过期时间，为空表示永不过期',
+  `last_used_at` timestamp null default null comment '最近使用时间',
+  `last_used_ip` varchar(64) not null default '' comment '最近使用IP',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  unique key `uk_key_hash` (`key_hash`), -- 唯一索引 key_hash
+  key `idx_user_id` (`user_id`), -- 索引 user_id
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_api_keys_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade -- 引用 users.id 并设置级联删除和更新
+) engine=innodb auto_increment=1 comment='个人 API Key 表';
+
 -- 创建角色表
 create table if not exists `roles` (
   `id` bigint unsigned not null auto_increment comment '角色id',
@@ -38,6 +116,7 @@ create table if not exists `roles` (
   `code` varchar(50) not null comment '角色代码',
   `status` tinyint unsigned not null default '1' comment '状态 1: 启用 2: 禁用',
   `remark` varchar(255) default null comment '备注',
+  `require_two_factor` boolean not null default false comment '拥有该角色的用户是否必须开启两步验证',
   `created_at` timestamp not null default current_timestamp comment '创建时间',
   `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
   `deleted_at` timestamp null default null comment '删除时间',
@@ -73,7 +152,7 @@ create table if not exists `permissions` (
   `name` varchar(50) not null comment '路由名称',
   -- `type` enum('menu', 'button') not null comment '权限类型, menu: 菜单, button: 按钮',
   `path` varchar(255) default null comment '菜单路径',
-  -- `code` varchar(50) default null comment '权限代码', -- 按钮权限的标识符
+  `code` varchar(50) default null comment '权限代码', -- 按钮权限的标识符，如 user:create
   `component` varchar(255) default null comment '组件路径', -- 菜单权限的组件
   `redirect` varchar(100) default null comment '重定向路径', -- 菜单权限的重定向路径
   `icon` varchar(255) default null comment '菜单图标',
@@ -89,7 +168,7 @@ create table if not exists `permissions` (
   `deleted_at` timestamp null default null comment '删除时间',
   primary key (`id`), -- 主键
   unique key `uk_path` (`path`), -- 唯一索引 path
-  -- unique key `uk_code` (`code`), -- 唯一索引 code
+  unique key `uk_code` (`code`), -- 唯一索引 code
   key `idx_parent_id` (`parent_id`), -- 索引 parent_id
   key `idx_deleted_at` (`deleted_at`) -- 索引 deleted_at
   -- constraint `chk_type_path_code` check ( -- 约束 type, path, code 
@@ -98,6 +177,46 @@ create table if not exists `permissions` (
   -- )
 ) engine=innodb auto_increment=1 comment='权限表';
 
+-- 初始化按钮权限（接口权限码，与 internal/router/routes 中声明的权限码保持一致）
+insert ignore into `permissions` (`title`, `name`, `code`, `visible`, `remark`) values
+  ('用户列表', 'UserList', 'user:list', false, '按钮权限'),
+  ('用户详情', 'UserDetail', 'user:detail', false, '按钮权限'),
+  ('新增用户', 'UserCreate', 'user:create', false, '按钮权限'),
+  ('编辑用户', 'UserUpdate', 'user:update', false, '按钮权限'),
+  ('删除用户', 'UserDelete', 'user:delete', false, '按钮权限'),
+  ('用户登录设备', 'UserSession', 'user:session', false, '按钮权限'),
+  ('重置两步验证', 'UserResetTwoFactor', 'user:reset_2fa', false, '按钮权限'),
+  ('解除登录锁定', 'UserUnlock', 'user:unlock', false, '按钮权限'),
+  ('模拟登录', 'UserImpersonate', 'user:impersonate', false, '按钮权限'),
+  ('角色列表', 'RoleList', 'role:list', false, '按钮权限'),
+  ('角色详情', 'RoleDetail', 'role:detail', false, '按钮权限'),
+  ('新增角色', 'RoleCreate', 'role:create', false, '按钮权限'),
+  ('编辑角色', 'RoleUpdate', 'role:update', false, '按钮权限'),
+  ('分配角色权限', 'RoleAssign', 'role:assign', false, '按钮权限'),
+  ('删除角色', 'RoleDelete', 'role:delete', false, '按钮权限'),
+  ('权限列表', 'PermissionList', 'permission:list', false, '按钮权限'),
+  ('权限详情', 'PermissionDetail', 'permission:detail', false, '按钮权限'),
+  ('新增权限', 'PermissionCreate', 'permission:create', false, '按钮权限'),
+  ('编辑权限', 'PermissionUpdate', 'permission:update', false, '按钮权限'),
+  ('删除权限', 'PermissionDelete', 'permission:delete', false, '按钮权限'),
+  ('导出权限', 'PermissionExport', 'permission:export', false, '按钮权限'),
+  ('日志列表', 'ApiLogList', 'api_log:list', false, '按钮权限'),
+  ('OAuth 客户端列表', 'OAuthClientList', 'oauth_client:list', false, '按钮权限'),
+  ('OAuth 客户端详情', 'OAuthClientDetail', 'oauth_client:detail', false, '按钮权限'),
+  ('新增 OAuth 客户端', 'OAuthClientCreate', 'oauth_client:create', false, '按钮权限'),
+  ('编辑 OAuth 客户端', 'OAuthClientUpdate', 'oauth_client:update', false, '按钮权限'),
+  ('删除 OAuth 客户端', 'OAuthClientDelete', 'oauth_client:delete', false, '按钮权限');
+
+-- 初始化超级管理员角色（拥有该角色的用户跳过权限码校验）
+insert ignore into `roles` (`name`, `code`, `remark`) values ('超级管理员', 'admin', '拥有所有权限');
+
+-- 将超级管理员角色授予初始管理员（不内置账号和密码）
+-- 先注册或创建用户名为 @admin_username 的账号，再执行本脚本；脚本可重复执行，用户不存在时不做任何修改
+set @admin_username = 'admin';
+insert ignore into `user_roles` (`user_id`, `role_id`)
+select `u`.`id`, `r`.`id` from `users` `u` join `roles` `r` on `r`.`code` = 'admin' and `r`.`deleted_at` is null
+where `u`.`username` = @admin_username and `u`.`deleted_at` is null;
+
 -- 创建角色权限关联表
 create table if not exists `role_permissions` (
   `id` bigint unsigned not null auto_increment comment 'ID',
@@ -132,8 +251,70 @@ create table if not exists `api_logs` (
   `duration` bigint not null comment '请求耗时(ms)',
   `response_body` text default null comment '响应体',
   `type` enum('operate', 'login') not null comment '日志类型, operate: 操作日志, login: 登录日志',
+  `event` varchar(32) not null default '' comment '登录事件 login_success / login_failure / login_locked / login_blocked / login_unlock / impersonate',
+  `actor_id` bigint unsigned not null default 0 comment '模拟登录的真实操作人id',
+  `actor_username` varchar(50) not null default '' comment '模拟登录的真实操作人用户名',
   `created_at` timestamp not null default current_timestamp comment '创建时间',
   `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
   `deleted_at` timestamp null default null comment '删除时间',
   primary key (`id`)
 ) engine=innodb auto_increment=1 comment='日志表';
+
+-- 创建 OAuth 客户端表（授权服务器接入的应用）
+create table if not exists `oauth_clients` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `client_id` varchar(64) not null comment '客户端ID',
+  `client_secret_hash` char(64) not null default '' comment '客户端密钥 SHA-256 哈希，公共客户端为空',
+  `name` varchar(50) not null comment '名称',
+  `redirect_uris` json not null comment '允许的回调地址',
+  `grant_types` json not null comment '允许的授权模式',
+  `scopes` json not null comment '允许申请的 scope',
+  `public` boolean not null default false comment '是否为公共客户端（必须使用 PKCE）',
+  `skip_consent` boolean not null default false comment '是否跳过授权确认',
+  `status` tinyint unsigned not null default '1' comment '状态 1: 启用 2: 禁用',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  unique key `uk_client_id` (`client_id`), -- 唯一索引 client_id
+  key `idx_deleted_at` (`deleted_at`) -- 索引 deleted_at
+) engine=innodb auto_increment=1 comment='OAuth 客户端表';
+
+-- 创建 OAuth 授权记录表
+create table if not exists `oauth_consents` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '用户id',
+  `client_id` varchar(64) not null comment '客户端ID',
+  `scopes` json not null comment '已授权的 scope',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  unique key `uk_user_client` (`user_id`, `client_id`), -- 联合唯一索引 user_id, client_id
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_oauth_consents_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade -- 引用 users.id 并设置级联删除和更新
+) engine=innodb auto_increment=1 comment='OAuth 授权记录表';
+
+-- 创建列表视图表
+create table if not exists `saved_views` (
+  `id` bigint unsigned not null auto_increment comment 'ID',
+  `user_id` bigint unsigned not null comment '创建者id',
+  `resource` varchar(50) not null comment '列表资源（表名）',
+  `name` varchar(50) not null comment '视图名称',
+  `params` json default null comment '查询条件，格式同列表的 params 参数',
+  `sort` varchar(255) not null default '' comment '排序，格式同列表的 sort 参数',
+  `fields` json default null comment '显示字段',
+  `role_id` bigint unsigned default null comment '共享给的角色id，为空表示不共享',
+  `created_at` timestamp not null default current_timestamp comment '创建时间',
+  `updated_at` timestamp not null default current_timestamp on update current_timestamp comment '更新时间',
+  `deleted_at` timestamp null default null comment '删除时间',
+  primary key (`id`), -- 主键
+  key `idx_user_resource` (`user_id`, `resource`), -- 联合索引 user_id, resource
+  key `idx_role_id` (`role_id`), -- 索引 role_id
+  key `idx_deleted_at` (`deleted_at`), -- 索引 deleted_at
+  constraint `fk_saved_views_user_id` foreign key (`user_id`) -- 外键 user_id
+  references `users` (`id`) on delete cascade on update cascade, -- 引用 users.id 并设置级联删除和更新
+  constraint `fk_saved_views_role_id` foreign key (`role_id`) -- 外键 role_id
+  references `roles` (`id`) on delete set null on update cascade -- 引用 roles.id，角色删除后取消共享
+) engine=innodb auto_increment=1 comment='列表视图表';